@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/JohnWall2016/gogetdef/lookup"
+	"io"
+	"strings"
+)
+
+// A batchResult is the answer to one query of the -batch mode.
+type batchResult struct {
+	Query   string         `json:"query"`
+	Pos     string         `json:"pos,omitempty"`
+	Kind    string         `json:"kind,omitempty"`
+	Type    string         `json:"type,omitempty"`
+	Decl    string         `json:"decl,omitempty"`
+	Import  string         `json:"import,omitempty"`
+	Doc     string         `json:"doc,omitempty"`
+	Value   string         `json:"value,omitempty"`
+	Warning string         `json:"warning,omitempty"`
+	Methods []batchRelated `json:"methods,omitempty"`
+	Impls   []batchRelated `json:"impls,omitempty"`
+	Links   []batchRelated `json:"links,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// A batchRelated is a declaration related to the result of a query.
+type batchRelated struct {
+	Decl string `json:"decl"`
+	Pos  string `json:"pos"`
+}
+
+// batchRelatedList converts list for a batchResult.
+func batchRelatedList(list []lookup.Related) []batchRelated {
+	var out []batchRelated
+	for _, r := range list {
+		out = append(out, batchRelated{r.Decl, r.Pos})
+	}
+	return out
+}
+
+// runBatch answers the positions read from r, one per line, up to an
+// empty line or the end of the input. With -modified, the archive of
+// modified files follows the empty line. All queries share the parsed
+// and type-checked packages, and the function bodies containing any of
+// them are checked together. The results are written to w as they are
+// found, as one JSON object per line and in the order of the queries.
+// The -timeout applies to each query.
+func runBatch(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	var queries []string
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimSpace(line); line == "" {
+			break
+		}
+		queries = append(queries, line)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	var archive io.Reader
+	if *modified {
+		archive = br
+	}
+	overlay, err := readOverlay(archive)
+	if err != nil {
+		return err
+	}
+
+	s := newSession()
+	positions := make([]lookup.Pos, len(queries))
+	errs := make([]error, len(queries))
+	var valid []lookup.Pos
+	for i, q := range queries {
+		if positions[i], errs[i] = parsePos(q); errs[i] == nil {
+			valid = append(valid, positions[i])
+		}
+	}
+	s.Prepare(valid, overlay)
+
+	enc := json.NewEncoder(w)
+	for i, q := range queries {
+		res := batchResult{Query: q}
+		err := errs[i]
+		if err == nil {
+			var def *lookup.Definition
+			ctx, cancel := lookupContext()
+			def, err = s.DefinitionAt(ctx, positions[i], overlay)
+			cancel()
+			if err == nil {
+				res.Pos, res.Decl = def.Pos, def.Decl
+				res.Kind, res.Type = def.Kind, def.Type
+				res.Import, res.Doc, res.Value = def.Import, def.Doc, def.Value
+				res.Warning = def.Warning
+				res.Methods = batchRelatedList(def.Methods)
+				res.Impls = batchRelatedList(def.Impls)
+				res.Links = batchRelatedList(def.Links)
+			}
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		if err := enc.Encode(&res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
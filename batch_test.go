@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testTree writes files, by their paths relative to a new GOPATH, and
+// makes it the GOPATH of the lookups until the returned function is
+// called.
+func testTree(t *testing.T, files map[string]string) (gopath string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "gogetdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// positions are printed with the symbolic links resolved
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		fileName := filepath.Join(dir, "src", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fileName, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldGopath := build.Default.GOPATH
+	build.Default.GOPATH = dir
+	return dir, func() {
+		build.Default.GOPATH = oldGopath
+		os.RemoveAll(dir)
+	}
+}
+
+// query returns the -pos of the first occurrence of at in the file name
+// of gopath, whose source is src.
+func query(gopath, name, src, at string) string {
+	return filepath.Join(gopath, "src", filepath.FromSlash(name)) + ":#" + strconv.Itoa(strings.Index(src, at))
+}
+
+func TestRunBatch(t *testing.T) {
+	aSrc := `package a
+
+import "b"
+
+func f() int {
+	return b.F() + g()
+}
+
+func g() int { return 2 }
+
+var _ = undefinedA
+`
+	bSrc := `package b
+
+// F returns one.
+func F() int { return 1 }
+`
+	cSrc := `package c
+
+var _ = undefinedC
+`
+	gopath, cleanup := testTree(t, map[string]string{"a/a.go": aSrc, "b/b.go": bSrc, "c/c.go": cSrc})
+	defer cleanup()
+	defer func(all bool) { *showall = all }(*showall)
+	*showall = true
+
+	queries := []string{
+		query(gopath, "a/a.go", aSrc, "F()"),
+		query(gopath, "a/a.go", aSrc, "g()"),
+		query(gopath, "a/a.go", aSrc, "undefinedA"),
+		query(gopath, "c/c.go", cSrc, "undefinedC"),
+		query(gopath, "b/b.go", bSrc, "F()"),
+		"bad query",
+	}
+	aFile := filepath.Join(gopath, "src", "a", "a.go")
+	bPos := filepath.Join(gopath, "src", "b", "b.go") + ":4:6"
+	want := []batchResult{
+		{Pos: bPos, Kind: "func", Type: "func() int", Decl: "func F() int", Import: "b", Doc: "F returns one.\n"},
+		{Pos: aFile + ":9:6", Kind: "func", Type: "func() int", Decl: "func g() int", Import: "a"},
+		{Error: aFile + ":11:9: undeclared name: undefinedA"},
+		// the errors of package a are not reported again
+		{Error: filepath.Join(gopath, "src", "c", "c.go") + ":3:9: undeclared name: undefinedC"},
+		{Pos: bPos, Kind: "func", Type: "func() int", Decl: "func F() int", Import: "b", Doc: "F returns one.\n"},
+		{Error: "invalid option: -pos=bad query"},
+	}
+
+	var out bytes.Buffer
+	if err := runBatch(strings.NewReader(strings.Join(queries, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&out)
+	for i, q := range queries {
+		var got batchResult
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("result %d: %v", i, err)
+		}
+		want[i].Query = q
+		if !resultsEqual(got, want[i]) {
+			t.Errorf("query %d:\ngot  %+v\nwant %+v", i, got, want[i])
+		}
+	}
+	if dec.More() {
+		t.Error("more results than queries")
+	}
+}
+
+func resultsEqual(a, b batchResult) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
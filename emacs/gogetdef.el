@@ -5,8 +5,8 @@
 
 You can install gogetdef with 'go get -u github.com/JohnWall2016/gogetdef'."
   (if (not (buffer-file-name (go--coverage-origin-buffer)))
-      ;; TODO: gogetdef supports unsaved files, but not introducing
-      ;; new artifical files, so this limitation will stay for now.
+      ;; gogetdef supports unsaved and new files, but it needs a file
+      ;; name to place the buffer in a package.
       (error "Cannot use gogetdef on a buffer without a file name"))
   (let ((buff (go--coverage-origin-buffer))
         (posn (if (eq system-type 'windows-nt)
@@ -64,8 +64,8 @@ You can install gogetdef with 'go get -u github.com/JohnWall2016/gogetdef'."
 
 You can install gogetdef with 'go get -u github.com/JohnWall2016/gogetdef'."
   (if (not (buffer-file-name (go--coverage-origin-buffer)))
-      ;; TODO: gogetdef supports unsaved files, but not introducing
-      ;; new artifical files, so this limitation will stay for now.
+      ;; gogetdef supports unsaved and new files, but it needs a file
+      ;; name to place the buffer in a package.
       (error "Cannot use gogetdef on a buffer without a file name"))
   (let ((buff (go--coverage-origin-buffer))
         (posn (if (eq system-type 'windows-nt)
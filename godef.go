@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/lookup"
+	"go/ast"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The flags of godef, see https://github.com/rogpeppe/godef, besides
+// -json. Using any of -f, -o and -i, or running the command as godef,
+// selects the godef mode, in which the results are printed in the formats
+// of godef.
+var (
+	godefFile   = flag.String("f", "", "godef mode: Go source filename")
+	godefOffset = flag.Int("o", -1, "godef mode: byte offset of the identifier in the file")
+	godefStdin  = flag.Bool("i", false, "godef mode: read the file contents from standard input")
+	godefType   = flag.Bool("t", false, "godef mode: print the type information")
+	godefPublic = flag.Bool("a", false, "godef mode: print the public type and member information")
+	godefAllMem = flag.Bool("A", false, "godef mode: print all the type and member information")
+	godefAcme   = flag.Bool("acme", false, "godef mode: use the current acme window (not supported)")
+	_           = flag.Bool("debug", false, "godef mode: ignored")
+)
+
+// godefMode reports whether gogetdef runs as a replacement of godef.
+func godefMode() bool {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	return name == "godef" || *godefFile != "" || *godefOffset >= 0 || *godefStdin
+}
+
+// godefFail reports an error the way godef does.
+func godefFail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "godef: %s\n", fmt.Sprintf(format, args...))
+	os.Exit(2)
+}
+
+// runGodef looks up the identifier selected with the godef flags and
+// prints its position and, with -t, -a or -A, its type and members.
+func runGodef() {
+	if *godefAcme {
+		godefFail("-acme is not supported")
+	}
+	if *godefFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *godefOffset < 0 {
+		godefFail("expressions are not supported, use -o to select an identifier")
+	}
+	def, err := godefLookup(os.Stdin)
+	if err != nil {
+		godefFail("%v", err)
+	}
+	if err := printGodef(os.Stdout, def); err != nil {
+		godefFail("%v", err)
+	}
+}
+
+// godefLookup looks up the identifier at -o in -f, whose contents are read
+// from stdin with -i.
+func godefLookup(stdin io.Reader) (*lookup.Definition, error) {
+	// godef prints absolute positions
+	fileName, err := filepath.Abs(*godefFile)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay, err := readOverlay(nil)
+	if err != nil {
+		return nil, err
+	}
+	if *godefStdin {
+		src, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read standard input: %v", err)
+		}
+		if overlay == nil {
+			overlay = make(map[string][]byte)
+		}
+		overlay[fileName] = src
+	}
+
+	ctx, cancel := lookupContext()
+	defer cancel()
+	return newGodefSession().Definition(ctx, fileName, *godefOffset, overlay)
+}
+
+// newGodefSession returns a lookup session configured by the godef flags.
+func newGodefSession() *lookup.Session {
+	return lookup.NewSession(lookup.Options{
+		GOOS:    *goos,
+		GOARCH:  *goarch,
+		Tags:    splitTags(*buildtags),
+		Members: *godefPublic || *godefAllMem,
+	})
+}
+
+// printGodef prints def in the format selected by the godef flags: its
+// position and, with -t, -a or -A, its type and members, or its position
+// as JSON with -json.
+func printGodef(w io.Writer, def *lookup.Definition) error {
+	if *jsonOut {
+		return printGodefJSON(w, def)
+	}
+	fmt.Fprintln(w, def.Pos)
+	if !*godefType && !*godefPublic && !*godefAllMem {
+		return nil
+	}
+	fmt.Fprintln(w, godefTypeStr(def))
+	for _, m := range def.Members {
+		// unexported members are only shown with -A
+		if !*godefAllMem && !ast.IsExported(m.Name) {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s\n", strings.Replace(godefTypeStr(m), "\n", "\n\t\t", -1))
+		if _, err := fmt.Fprintf(w, "\t\t%s\n", m.Pos); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printGodefJSON prints the position of def as godef -json does.
+func printGodefJSON(w io.Writer, def *lookup.Definition) error {
+	var out struct {
+		Filename string `json:"filename,omitempty"`
+		Line     int    `json:"line,omitempty"`
+		Column   int    `json:"column,omitempty"`
+	}
+	out.Filename, out.Line, out.Column = splitPos(def.Pos)
+	return json.NewEncoder(w).Encode(&out)
+}
+
+// splitPos splits a position printed by token.Position, which is a file
+// name optionally followed by a line and a column.
+func splitPos(p string) (fileName string, line, col int) {
+	csep := strings.LastIndex(p, ":")
+	if csep <= 0 {
+		return p, 0, 0
+	}
+	lsep := strings.LastIndex(p[:csep], ":")
+	if lsep <= 0 {
+		return p, 0, 0
+	}
+	line, err1 := strconv.Atoi(p[lsep+1 : csep])
+	col, err2 := strconv.Atoi(p[csep+1:])
+	if err1 != nil || err2 != nil {
+		return p, 0, 0
+	}
+	return p[:lsep], line, col
+}
+
+// godefTypeStr describes def as godef -t does, e.g. "Title func(s string)
+// string", "type T struct{X int}" or "import (fmt "fmt")", also for the
+// path of the import.
+func godefTypeStr(def *lookup.Definition) string {
+	buf := &bytes.Buffer{}
+	valueFormat := " = %s"
+	switch def.Kind {
+	case lookup.KindVar, lookup.KindFunc, "":
+		// the name says it
+	case lookup.KindImport, lookup.KindPath:
+		// the path of an import stands for the package it imports
+		valueFormat = " %s)"
+		fmt.Fprint(buf, lookup.KindImport, " (")
+	default:
+		fmt.Fprint(buf, def.Kind, " ")
+	}
+	fmt.Fprint(buf, def.Name)
+	if def.Type != "" {
+		fmt.Fprintf(buf, " %s", def.Type)
+	}
+	if def.Constant != "" {
+		fmt.Fprintf(buf, valueFormat, def.Constant)
+	}
+	return buf.String()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// checkGolden compares got with the golden file name of testdata, in
+// which $DIR stands for the directory of the test files and $GOROOT for
+// the Go root.
+func checkGolden(t *testing.T, name, dir, got string) {
+	t.Helper()
+	golden, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer("$DIR", dir, "$GOROOT", runtime.GOROOT()).Replace(string(golden))
+	if got != want {
+		t.Errorf("%s: got\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestGodefOutput(t *testing.T) {
+	dir, err := filepath.Abs(filepath.Join("testdata", "godef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(dir, "p.go")
+	src, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		flag    *bool
+		queries []string
+		golden  string
+	}{
+		{godefType, []string{"v.M", "M()", "C)", `"fmt"`, "fmt.P"}, "godef-t.golden"},
+		{godefPublic, []string{"T\n\tv"}, "godef-a.golden"},
+		{godefAllMem, []string{"T\n\tv"}, "godef-A.golden"},
+		{jsonOut, []string{"v.M", `"fmt"`}, "godef-json.golden"},
+	}
+	for _, test := range tests {
+		*test.flag = true
+		s := newGodefSession()
+		var out bytes.Buffer
+		for _, at := range test.queries {
+			def, err := s.Definition(context.Background(), fileName, bytes.Index(src, []byte(at)), nil)
+			if err != nil {
+				t.Fatalf("%s: %v", at, err)
+			}
+			if err := printGodef(&out, def); err != nil {
+				t.Fatal(err)
+			}
+		}
+		*test.flag = false
+		checkGolden(t, test.golden, dir, out.String())
+	}
+}
+
+func TestGodefRelativeFile(t *testing.T) {
+	fileName := filepath.Join("testdata", "godef", "p.go")
+	src, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(file string, offset int, stdin bool) {
+		*godefFile, *godefOffset, *godefStdin = file, offset, stdin
+	}(*godefFile, *godefOffset, *godefStdin)
+	// the edited source read from stdin declares v a line later
+	src = bytes.Replace(src, []byte("func f() {"), []byte("func f() {\n"), 1)
+	*godefFile, *godefOffset, *godefStdin = fileName, bytes.Index(src, []byte("v.M")), true
+	def, err := godefLookup(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs, _ := filepath.Abs(fileName)
+	if want := abs + ":19:6"; def.Pos != want {
+		t.Errorf("got %s, want %s", def.Pos, want)
+	}
+}
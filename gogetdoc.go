@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/lookup"
+	"go/doc"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The flags of gogetdoc, see https://github.com/zmb3/gogetdoc, besides
+// -pos, -modified and -tags. Using -json without the godef flags, or
+// running the command as gogetdoc, selects the gogetdoc mode, in which the
+// result is printed as gogetdoc does.
+var (
+	unexported = flag.Bool("u", false, "gogetdoc mode: show the unexported fields and methods of types")
+	linelength = flag.Int("linelength", 80, "maximum length of the lines of the printed documentation")
+)
+
+// gogetdocMode reports whether gogetdef runs as a replacement of gogetdoc.
+func gogetdocMode() bool {
+	if *batch {
+		return false
+	}
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	return name == "gogetdoc" || (*jsonOut && !godefMode())
+}
+
+// A gogetdocResult is the document printed by gogetdoc -json.
+type gogetdocResult struct {
+	Name   string `json:"name,omitempty"`
+	Import string `json:"import,omitempty"`
+	Pkg    string `json:"pkg,omitempty"`
+	Decl   string `json:"decl,omitempty"`
+	Doc    string `json:"doc,omitempty"`
+	Pos    string `json:"pos,omitempty"`
+}
+
+// printGogetdoc prints def as gogetdoc does: as JSON with -json, else as
+// the import path, the declaration and the documentation.
+func printGogetdoc(w io.Writer, def *lookup.Definition) error {
+	d := gogetdocResult{
+		Name:   def.Name,
+		Import: def.Import,
+		Pkg:    def.Pkg,
+		Decl:   def.Decl,
+		Doc:    def.Doc,
+		Pos:    def.Pos,
+	}
+	if *jsonOut {
+		// the comment text is given as is, only the text output is wrapped
+		return json.NewEncoder(w).Encode(&d)
+	}
+	buf := &bytes.Buffer{}
+	doc.ToText(buf, def.Doc, "", "    ", *linelength)
+	d.Doc = buf.String()
+	if d.Import != "" {
+		fmt.Fprintf(w, "import \"%s\"\n\n", d.Import)
+	}
+	fmt.Fprintf(w, "%s\n\n", d.Decl)
+	if d.Doc == "" {
+		d.Doc = "Undocumented."
+	}
+	_, err := fmt.Fprintln(w, d.Doc)
+	return err
+}
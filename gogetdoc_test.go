@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gogetdocSrc = `package doc
+
+// T is a type.
+type T struct {
+	X int
+	y string
+}
+
+// G has a documentation long enough to be wrapped when the lines are
+// limited to forty characters.
+//
+//	G()
+func G() {}
+
+var _ = T{}
+`
+
+func TestGogetdocOutput(t *testing.T) {
+	gopath, cleanup := testTree(t, map[string]string{"example.com/doc/doc.go": gogetdocSrc})
+	defer cleanup()
+	dir := filepath.Join(gopath, "src", "example.com", "doc")
+	fileName := filepath.Join(dir, "doc.go")
+
+	defer func(args0 string, json, u bool, n int) {
+		os.Args[0], *jsonOut, *unexported, *linelength = args0, json, u, n
+	}(os.Args[0], *jsonOut, *unexported, *linelength)
+	os.Args[0] = "gogetdoc"
+
+	tests := []struct {
+		json, u bool
+		n       int
+		golden  string
+	}{
+		{false, false, 80, "gogetdoc.golden"},
+		{false, true, 40, "gogetdoc-u.golden"},
+		{true, false, 40, "gogetdoc-json.golden"},
+	}
+	for _, test := range tests {
+		*jsonOut, *unexported, *linelength = test.json, test.u, test.n
+		if !gogetdocMode() {
+			t.Fatal("not in gogetdoc mode")
+		}
+		s := newSession()
+		var out bytes.Buffer
+		for _, at := range []string{"T{}", "G() {"} {
+			def, err := s.Definition(context.Background(), fileName, strings.Index(gogetdocSrc, at), nil)
+			if err != nil {
+				t.Fatalf("%s: %v", at, err)
+			}
+			if err := printGogetdoc(&out, def); err != nil {
+				t.Fatal(err)
+			}
+		}
+		checkGolden(t, test.golden, dir, out.String())
+	}
+}
+
+func TestPrintAllLineLength(t *testing.T) {
+	gopath, cleanup := testTree(t, map[string]string{"example.com/doc/doc.go": gogetdocSrc})
+	defer cleanup()
+	dir := filepath.Join(gopath, "src", "example.com", "doc")
+	defer func(all bool, n int) { *showall, *linelength = all, n }(*showall, *linelength)
+	*showall, *linelength = true, 40
+
+	def, err := newSession().Definition(context.Background(), filepath.Join(dir, "doc.go"), strings.Index(gogetdocSrc, "G() {"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	printAll(&out, def)
+	checkGolden(t, "all.golden", dir, out.String())
+}
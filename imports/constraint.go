@@ -0,0 +1,164 @@
+package imports
+
+import (
+	"bytes"
+	"go/build"
+	"go/build/constraint"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ReadConstraint returns the build constraint in the header of the Go
+// source src, or nil if there is none. As with the go command, a
+// //go:build line takes precedence over // +build lines, which are
+// only honoured when followed by a blank line.
+func ReadConstraint(src []byte) (constraint.Expr, error) {
+	var goBuild string
+	var plusBuild, pending []string
+	inComment := false
+	for len(src) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(src, '\n'); i >= 0 {
+			line, src = src[:i], src[i+1:]
+		} else {
+			line, src = src, nil
+		}
+		line = bytes.TrimSpace(line)
+		if inComment {
+			if i := bytes.Index(line, []byte("*/")); i >= 0 {
+				inComment = false
+				if rest := bytes.TrimSpace(line[i+2:]); len(rest) > 0 && !bytes.HasPrefix(rest, []byte("//")) {
+					break
+				}
+			}
+			continue
+		}
+		switch {
+		case len(line) == 0:
+			plusBuild = append(plusBuild, pending...)
+			pending = nil
+		case bytes.HasPrefix(line, []byte("//")):
+			s := string(line)
+			if constraint.IsGoBuild(s) && goBuild == "" {
+				goBuild = s
+			} else if constraint.IsPlusBuild(s) {
+				pending = append(pending, s)
+			}
+		case bytes.HasPrefix(line, []byte("/*")):
+			if !bytes.Contains(line[2:], []byte("*/")) {
+				inComment = true
+			}
+		default:
+			src = nil
+		}
+	}
+
+	if goBuild != "" {
+		return constraint.Parse(goBuild)
+	}
+	var x constraint.Expr
+	for _, line := range plusBuild {
+		y, err := constraint.Parse(line)
+		if err != nil {
+			return nil, err
+		}
+		if x == nil {
+			x = y
+		} else {
+			x = &constraint.AndExpr{X: x, Y: y}
+		}
+	}
+	return x, nil
+}
+
+// ConstraintTags returns the tags mentioned in the build constraint x.
+func ConstraintTags(x constraint.Expr) (tags []string) {
+	seen := make(map[string]bool)
+	var walk func(x constraint.Expr)
+	walk = func(x constraint.Expr) {
+		switch x := x.(type) {
+		case *constraint.TagExpr:
+			if !seen[x.Tag] && !strings.HasPrefix(x.Tag, "go1.") {
+				seen[x.Tag] = true
+				tags = append(tags, x.Tag)
+			}
+		case *constraint.NotExpr:
+			walk(x.X)
+		case *constraint.AndExpr:
+			walk(x.X)
+			walk(x.Y)
+		case *constraint.OrExpr:
+			walk(x.X)
+			walk(x.Y)
+		}
+	}
+	walk(x)
+	return
+}
+
+// MatchFile reports whether the file name in dir would be included in
+// the package built with ctxt. Besides the _GOOS and _GOARCH file name
+// suffixes, the file's //go:build expression is evaluated in full, with
+// // +build lines used only when no //go:build line is present.
+func MatchFile(ctxt *build.Context, dir, name string) (bool, error) {
+	// Let go/build judge the file name alone.
+	nameCtxt := *ctxt
+	nameCtxt.OpenFile = func(path string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("package p\n")), nil
+	}
+	if ok, err := nameCtxt.MatchFile(dir, name); err != nil || !ok {
+		return false, err
+	}
+	if !strings.HasSuffix(name, ".go") {
+		return true, nil
+	}
+
+	rc, err := OpenFile(ctxt, JoinPath(ctxt, dir, name))
+	if err != nil {
+		return false, err
+	}
+	src, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return false, err
+	}
+	x, err := ReadConstraint(src)
+	if err != nil || x == nil {
+		return err == nil, err
+	}
+	return x.Eval(func(tag string) bool { return MatchTag(ctxt, tag) }), nil
+}
+
+// MatchTag reports whether the build tag is satisfied by ctxt, following
+// the rules of the go command.
+func MatchTag(ctxt *build.Context, tag string) bool {
+	switch {
+	case tag == ctxt.GOOS || tag == ctxt.GOARCH || tag == ctxt.Compiler:
+		return true
+	case tag == "cgo":
+		return ctxt.CgoEnabled
+	case tag == "unix":
+		return unixOS[ctxt.GOOS]
+	case tag == "linux" && ctxt.GOOS == "android":
+		return true
+	case tag == "solaris" && ctxt.GOOS == "illumos":
+		return true
+	case tag == "darwin" && ctxt.GOOS == "ios":
+		return true
+	}
+	for _, tags := range [][]string{ctxt.BuildTags, ctxt.ToolTags, ctxt.ReleaseTags} {
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "linux": true, "netbsd": true,
+	"openbsd": true, "solaris": true,
+}
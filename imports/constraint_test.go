@@ -0,0 +1,43 @@
+package imports
+
+import (
+	"go/build"
+	"testing"
+)
+
+func TestMatchFile(t *testing.T) {
+	overlay := map[string][]byte{
+		"/src/a/plus.go":  []byte("// +build linux\n\npackage a\n"),
+		"/src/a/gobld.go": []byte("//go:build linux && !(integration || short)\n\npackage a\n"),
+		"/src/a/both.go":  []byte("//go:build windows\n// +build linux\n\npackage a\n"),
+		"/src/a/doc.go":   []byte("// +build windows\npackage a\n"),
+		"/src/a/a_arm.go": []byte("package a\n"),
+	}
+	ctxt := build.Default
+	ctxt.GOOS, ctxt.GOARCH, ctxt.BuildTags = "linux", "amd64", nil
+	octxt := OverlayContext(&ctxt, overlay)
+
+	tests := []struct {
+		name string
+		tags []string
+		want bool
+	}{
+		{"plus.go", nil, true},
+		{"gobld.go", nil, true},
+		{"gobld.go", []string{"short"}, false},
+		{"both.go", nil, false},
+		{"doc.go", nil, true},
+		{"a_arm.go", nil, false},
+	}
+	for _, test := range tests {
+		octxt.BuildTags = test.tags
+		got, err := MatchFile(octxt, "/src/a", test.name)
+		if err != nil {
+			t.Errorf("MatchFile(%s): %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("MatchFile(%s) with tags %v = %v, want %v", test.name, test.tags, got, test.want)
+		}
+	}
+}
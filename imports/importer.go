@@ -7,6 +7,7 @@
 package imports
 
 import (
+	"context"
 	"fmt"
 	"github.com/JohnWall2016/gogetdef/parser"
 	"github.com/JohnWall2016/gogetdef/types"
@@ -24,12 +25,18 @@ import (
 type Importer struct {
 	ctxt         *build.Context
 	fset         *token.FileSet
-	sizes        types.Sizes
-	typPkgs      map[string]*types.Package
+	typPkgs      map[string]*types.Package // by pkgKey
+	dirs         map[string]string         // package directories, by pkgKey
 	astPkgs      *astPkgCache
 	info         *types.Info
 	IncludeTests func(pkg string) bool
 	mode         parser.Mode
+
+	// If Tolerant is set, packages with parse or type errors are not
+	// dropped: the partially populated package is kept, marked
+	// incomplete, and its errors are available from Errors.
+	Tolerant bool
+	errs     map[string][]error // by pkgKey
 }
 
 type astPkgCache struct {
@@ -66,6 +73,36 @@ func (c *astPkgCache) cacheFile(name string, file *ast.File) {
 	}
 }
 
+func (c *astPkgCache) forgetFile(name string) {
+	c.Lock()
+	defer c.Unlock()
+	for _, pkg := range c.packages {
+		delete(pkg.Files, name)
+	}
+}
+
+func (c *astPkgCache) forgetDirs(inDirs func(dir string) bool) {
+	c.Lock()
+	defer c.Unlock()
+	for _, pkg := range c.packages {
+		for name := range pkg.Files {
+			if inDirs(filepath.Dir(name)) {
+				delete(pkg.Files, name)
+			}
+		}
+	}
+}
+
+func (c *astPkgCache) allPackages() []*ast.Package {
+	c.RLock()
+	defer c.RUnlock()
+	pkgs := make([]*ast.Package, 0, len(c.packages))
+	for _, pkg := range c.packages {
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs
+}
+
 func (c *astPkgCache) cachedPackage(pkgName string) (pkg *ast.Package, ok bool) {
 	c.RLock()
 	defer c.RUnlock()
@@ -83,12 +120,46 @@ func NewImporter(ctxt *build.Context, fset *token.FileSet, info *types.Info, mod
 	return &Importer{
 		ctxt:    ctxt,
 		fset:    fset,
-		sizes:   types.SizesFor(ctxt.Compiler, ctxt.GOARCH), // uses go/types default if GOARCH not found
 		typPkgs: make(map[string]*types.Package),
+		dirs:    make(map[string]string),
 		astPkgs: &astPkgCache{packages: make(map[string]*ast.Package)},
 		info:    info,
 		mode:    mode,
+		errs:    make(map[string][]error),
+	}
+}
+
+// Errors returns the errors reported while importing the package with
+// the given import path.
+func (p *Importer) Errors(path string) []error {
+	return p.errs[p.pkgKey(path)]
+}
+
+// SetContext sets the build context of the following imports. Packages
+// imported with another context are kept for when it is set again.
+func (p *Importer) SetContext(ctxt *build.Context) {
+	p.ctxt = ctxt
+}
+
+// ContextKey identifies the settings of ctxt that select the files of
+// packages.
+func ContextKey(ctxt *build.Context) string {
+	key := ctxt.GOOS + "/" + ctxt.GOARCH + " " + strings.Join(ctxt.BuildTags, ",")
+	if ctxt.CgoEnabled {
+		key += " cgo"
+	}
+	return key
+}
+
+// pkgKey returns the key of the package with the given import path in the
+// maps of the importer. A package is imported again when the build
+// context or the inclusion of its test files changes.
+func (p *Importer) pkgKey(path string) string {
+	key := path + " " + ContextKey(p.ctxt)
+	if p.IncludeTests != nil && p.IncludeTests(path) {
+		key += " tests"
 	}
+	return key
 }
 
 // Importing is a sentinel taking the place in Importer.packages
@@ -107,6 +178,18 @@ func (p *Importer) Import(path string) (*types.Package, error) {
 // Packages that are not comprised entirely of pure Go files may fail to import because the
 // type checker may not be able to determine all exported entities (e.g. due to cgo dependencies).
 func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*types.Package, error) {
+	return p.ImportFromContext(context.Background(), path, srcDir, mode)
+}
+
+// ImportFromContext is like ImportFrom, but stops parsing and type-checking
+// the package and its dependencies when ctx is done. The partially checked
+// package is then returned, marked incomplete, together with the context's
+// error; it is not kept, so that a later import starts over.
+func (p *Importer) ImportFromContext(ctx context.Context, path, srcDir string, mode types.ImportMode) (*types.Package, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// determine package path (do vendor resolution)
 	var bp *build.Package
 	var err error
@@ -134,7 +217,8 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 	}
 
 	// no need to re-import if the package was imported completely before
-	pkg := p.typPkgs[bp.ImportPath]
+	key := p.pkgKey(bp.ImportPath)
+	pkg := p.typPkgs[key]
 	if pkg != nil {
 		if pkg == &importing {
 			return nil, fmt.Errorf("import cycle through package %q", bp.ImportPath)
@@ -149,22 +233,23 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 		return pkg, nil
 	}
 
-	p.typPkgs[bp.ImportPath] = &importing
+	p.typPkgs[key] = &importing
 	defer func() {
 		// clean up in case of error
 		// TODO(gri) Eventually we may want to leave a (possibly empty)
 		// package in the map in all cases (and use that package to
 		// identify cycles). See also issue 16088.
-		if p.typPkgs[bp.ImportPath] == &importing {
-			p.typPkgs[bp.ImportPath] = nil
+		if p.typPkgs[key] == &importing {
+			p.typPkgs[key] = nil
 		}
 	}()
 
-	// collect package files
+	// collect package files, ImportDir applies the build constraints
 	bp, err = p.ctxt.ImportDir(bp.Dir, 0)
 	if err != nil {
 		return nil, err // err may be *build.NoGoError - return as is
 	}
+	p.dirs[key] = bp.Dir
 	var filenames []string
 	filenames = append(filenames, bp.GoFiles...)
 	filenames = append(filenames, bp.CgoFiles...)
@@ -172,9 +257,20 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 		filenames = append(filenames, bp.TestGoFiles...)
 	}
 
-	files, err := p.parseFiles(bp.Dir, filenames, p.mode, nil)
+	files, err := p.parseFiles(ctx, bp.Dir, filenames, p.mode, nil)
 	if err != nil {
-		return nil, err
+		if !p.Tolerant || ctx.Err() != nil {
+			return nil, err
+		}
+		// continue with whatever could be parsed
+		p.errs[key] = append(p.errs[key], err)
+		parsed := files[:0]
+		for _, f := range files {
+			if f != nil {
+				parsed = append(parsed, f)
+			}
+		}
+		files = parsed
 	}
 
 	// type-check package files
@@ -187,11 +283,28 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 			if firstHardErr == nil && !err.(types.Error).Soft {
 				firstHardErr = err
 			}
+			if p.Tolerant {
+				p.errs[key] = append(p.errs[key], err)
+			}
 		},
-		Importer: p,
-		Sizes:    p.sizes,
+		Importer: p.WithContext(ctx),
+		Sizes:    types.SizesFor(p.ctxt.Compiler, p.ctxt.GOARCH), // uses go/types default if GOARCH not found
+		Context:  ctx,
 	}
 	pkg, err = conf.Check(bp.ImportPath, p.fset, files, p.info, mode)
+	if ctx.Err() != nil {
+		pkg.MarkIncomplete()
+		return pkg, ctx.Err()
+	}
+	if p.Tolerant && len(p.errs[key]) > 0 {
+		// Keep the package for the objects that were declared
+		// successfully. As it is incomplete, a later import returns
+		// it together with an error, and the type checker treats it
+		// as a fake package without reporting lookup failures.
+		pkg.MarkIncomplete()
+		p.typPkgs[key] = pkg
+		return pkg, fmt.Errorf("package %q has errors (%v)", bp.ImportPath, p.errs[key][0])
+	}
 	if err != nil {
 		// If there was a hard error it is possibly unsafe
 		// to use the package as it may not be fully populated.
@@ -207,11 +320,77 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 		panic("package is not safe yet no error was returned")
 	}
 
-	p.typPkgs[bp.ImportPath] = pkg
+	p.typPkgs[key] = pkg
 	return pkg, nil
 }
 
-func (p *Importer) parseFiles(dir string, filenames []string, mode parser.Mode, parseFuncBodies parser.InFuncBodies) ([]*ast.File, error) {
+// ForgetDirs drops the packages in the given directories together with
+// their parsed files, and the packages importing them, so that they are
+// imported again, e.g. after their files changed. The other packages are
+// kept.
+func (p *Importer) ForgetDirs(dirs []string) {
+	inDirs := func(dir string) bool {
+		for _, d := range dirs {
+			if dir == d || SameFile(dir, d) {
+				return true
+			}
+		}
+		return false
+	}
+	stale := make(map[*types.Package]bool)
+	forget := func(key string) {
+		if pkg := p.typPkgs[key]; pkg != nil {
+			stale[pkg] = true
+		}
+		delete(p.typPkgs, key)
+		delete(p.errs, key)
+		delete(p.dirs, key)
+	}
+	for key, dir := range p.dirs {
+		if inDirs(dir) {
+			forget(key)
+		}
+	}
+	// the packages importing a forgotten one refer to its objects
+	for again := len(stale) > 0; again; {
+		again = false
+		for key, pkg := range p.typPkgs {
+			if pkg == nil || pkg == &importing {
+				continue
+			}
+			for _, imp := range pkg.Imports() {
+				if stale[imp] {
+					forget(key)
+					again = true
+					break
+				}
+			}
+		}
+	}
+	p.astPkgs.forgetDirs(inDirs)
+}
+
+// A contextImporter imports with the context of the package importing.
+type contextImporter struct {
+	p   *Importer
+	ctx context.Context
+}
+
+func (c contextImporter) Import(path string) (*types.Package, error) {
+	return c.p.ImportFromContext(c.ctx, path, "", types.NoCheckCycleInDecl|types.NoCheckUsage)
+}
+
+func (c contextImporter) ImportFrom(path, srcDir string, mode types.ImportMode) (*types.Package, error) {
+	return c.p.ImportFromContext(c.ctx, path, srcDir, mode)
+}
+
+// WithContext returns an importer for types.Config that imports with
+// ImportFromContext.
+func (p *Importer) WithContext(ctx context.Context) types.ImporterFrom {
+	return contextImporter{p, ctx}
+}
+
+func (p *Importer) parseFiles(ctx context.Context, dir string, filenames []string, mode parser.Mode, parseFuncBodies parser.InFuncBodies) ([]*ast.File, error) {
 	open := p.ctxt.OpenFile // possibly nil
 
 	files := make([]*ast.File, len(filenames))
@@ -225,6 +404,8 @@ func (p *Importer) parseFiles(dir string, filenames []string, mode parser.Mode,
 			file, cached := p.astPkgs.cachedFile(filepath)
 			if cached {
 				files[i], errors[i] = file, nil
+			} else if err := ctx.Err(); err != nil {
+				errors[i] = err
 			} else {
 				if open != nil {
 					src, err := open(filepath)
@@ -242,7 +423,9 @@ func (p *Importer) parseFiles(dir string, filenames []string, mode parser.Mode,
 					// TODO(gri) investigate performance difference (issue #19281)
 					files[i], errors[i] = parser.ParseFile(p.fset, filepath, nil, mode, parseFuncBodies)
 				}
-				if errors[i] == nil {
+				// In tolerant mode, files with syntax errors are kept
+				// as far as they could be parsed.
+				if errors[i] == nil || (p.Tolerant && files[i] != nil) {
 					p.astPkgs.cacheFile(filepath, files[i])
 				}
 			}
@@ -250,16 +433,32 @@ func (p *Importer) parseFiles(dir string, filenames []string, mode parser.Mode,
 	}
 	wg.Wait()
 
-	// if there are errors, return the first one for deterministic results
+	// if there are errors, return the first one for deterministic results,
+	// together with the files parsed (possibly partially) so far
+	if err := ctx.Err(); err != nil {
+		return files, err
+	}
 	for _, err := range errors {
 		if err != nil {
-			return nil, err
+			return files, err
 		}
 	}
 
 	return files, nil
 }
 
+// matchFiles returns the filenames in dir whose build constraints
+// are satisfied by the importer's context, leaving filenames unchanged.
+func (p *Importer) matchFiles(dir string, filenames []string) []string {
+	matched := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		if ok, err := MatchFile(p.ctxt, dir, filename); err == nil && ok {
+			matched = append(matched, filename)
+		}
+	}
+	return matched
+}
+
 // context-controlled file system operations
 
 func (p *Importer) absPath(path string) (string, error) {
@@ -303,15 +502,17 @@ func (p *Importer) openFile(path string) ([]byte, error) {
 	return ioutil.ReadFile(path)
 }
 
-func (p *Importer) ParseFile(fileName string, parseFuncBodies parser.InFuncBodies) (*ast.File, error) {
-	astFiles, err := p.parseFiles("", []string{fileName}, p.mode, parseFuncBodies)
-	if err != nil {
+func (p *Importer) ParseFile(ctx context.Context, fileName string, parseFuncBodies parser.InFuncBodies) (*ast.File, error) {
+	astFiles, err := p.parseFiles(ctx, "", []string{fileName}, p.mode, parseFuncBodies)
+	if astFiles == nil {
 		return nil, err
 	}
-	return astFiles[0], nil
+	return astFiles[0], err // a partial file in case of syntax errors
 }
 
-func (p *Importer) ParseDir(dir string) ([]*ast.File, error) {
+// ParseDir parses the Go files in dir that match the build constraints
+// of the importer's context. The files left when ctx is done are nil.
+func (p *Importer) ParseDir(ctx context.Context, dir string) ([]*ast.File, error) {
 	list, err := p.readDir(dir)
 	if err != nil {
 		return nil, err
@@ -322,7 +523,7 @@ func (p *Importer) ParseDir(dir string) ([]*ast.File, error) {
 			fileNames = append(fileNames, f.Name())
 		}
 	}
-	return p.parseFiles(dir, fileNames, p.mode, nil)
+	return p.parseFiles(ctx, dir, p.matchFiles(dir, fileNames), p.mode, nil)
 }
 
 func (p *Importer) PathEnclosingInterval(fileName string, start, end token.Pos) []ast.Node {
@@ -333,6 +534,22 @@ func (p *Importer) PathEnclosingInterval(fileName string, start, end token.Pos)
 	return []ast.Node{}
 }
 
+// CachedFile returns the file fileName if it has been parsed.
+func (p *Importer) CachedFile(fileName string) (*ast.File, bool) {
+	return p.astPkgs.cachedFile(fileName)
+}
+
+// ForgetFile drops fileName from the parsed files, so that it is parsed
+// again, e.g. with other function bodies. Imported packages are kept.
+func (p *Importer) ForgetFile(fileName string) {
+	p.astPkgs.forgetFile(fileName)
+}
+
 func (p *Importer) GetCachedPackage(pkgName string) (*ast.Package, bool) {
 	return p.astPkgs.cachedPackage(pkgName)
 }
+
+// CachedPackages returns all packages whose files have been parsed so far.
+func (p *Importer) CachedPackages() []*ast.Package {
+	return p.astPkgs.allPackages()
+}
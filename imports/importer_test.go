@@ -0,0 +1,179 @@
+package imports
+
+import (
+	"context"
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/build"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportFromContextCanceled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctxt := build.Default
+	ctxt.GOPATH = dir
+	overlay := map[string][]byte{
+		filepath.Join(dir, "src", "a", "a.go"): []byte("package a\n\nconst A = 1\n"),
+	}
+	fset := token.NewFileSet()
+	p := NewImporter(OverlayContext(&ctxt, overlay), fset, nil, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.ImportFromContext(ctx, "a", dir, 0); err != context.Canceled {
+		t.Errorf("ImportFromContext with a canceled context = %v, want %v", err, context.Canceled)
+	}
+	// the canceled import is not kept
+	pkg, err := p.ImportFrom("a", dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pkg.Complete() || pkg.Scope().Lookup("A") == nil {
+		t.Errorf("package a is not completely imported")
+	}
+}
+
+func TestImportTolerant(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctxt := build.Default
+	ctxt.GOPATH = dir
+	overlay := map[string][]byte{
+		filepath.Join(dir, "src", "b", "b.go"): []byte("package b\n\nfunc F() int { return 1 }\n\nfunc G( {\n"),
+		filepath.Join(dir, "src", "a", "a.go"): []byte("package a\n\nimport \"b\"\n\nvar A = b.F()\n"),
+	}
+	fset := token.NewFileSet()
+	p := NewImporter(OverlayContext(&ctxt, overlay), fset, nil, 0)
+	p.Tolerant = true
+
+	// the errors of b make a incomplete, but both are kept
+	pkg, err := p.ImportFrom("a", dir, 0)
+	if pkg == nil {
+		t.Fatal(err)
+	}
+	if err == nil {
+		t.Errorf("no error importing package a with a broken dependency")
+	}
+	if pkg.Scope().Lookup("A") == nil {
+		t.Errorf("A is not declared in package a")
+	}
+	var b *types.Package
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == "b" {
+			b = imp
+		}
+	}
+	if b == nil {
+		t.Fatal("package b is not imported")
+	}
+	if b.Complete() {
+		t.Errorf("package b with a syntax error is complete")
+	}
+	if b.Scope().Lookup("F") == nil {
+		t.Errorf("F is not declared in package b")
+	}
+	if len(p.Errors("b")) == 0 {
+		t.Errorf("no errors recorded for package b")
+	}
+}
+
+func TestImportKeyedBySettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctxt := build.Default
+	ctxt.GOPATH = dir
+	ctxt.GOOS = "linux"
+	overlay := map[string][]byte{
+		filepath.Join(dir, "src", "b", "b.go"):         []byte("package b\n\nvar B int\n"),
+		filepath.Join(dir, "src", "b", "b_test.go"):    []byte("package b\n\nvar T int\n"),
+		filepath.Join(dir, "src", "b", "b_windows.go"): []byte("package b\n\nvar W int\n"),
+	}
+	fset := token.NewFileSet()
+	octxt := OverlayContext(&ctxt, overlay)
+	p := NewImporter(octxt, fset, nil, 0)
+
+	tests := []struct {
+		goos         string
+		includeTests bool
+		declared     []string
+		missing      []string
+	}{
+		{"linux", false, []string{"B"}, []string{"T", "W"}},
+		{"linux", true, []string{"B", "T"}, []string{"W"}},
+		{"windows", false, []string{"B", "W"}, []string{"T"}},
+		{"linux", false, []string{"B"}, []string{"T", "W"}},
+	}
+	for _, test := range tests {
+		octxt.GOOS = test.goos
+		p.IncludeTests = nil
+		if test.includeTests {
+			p.IncludeTests = func(path string) bool { return path == "b" }
+		}
+		pkg, err := p.ImportFrom("b", dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range test.declared {
+			if pkg.Scope().Lookup(name) == nil {
+				t.Errorf("%s, tests %v: %s is not declared", test.goos, test.includeTests, name)
+			}
+		}
+		for _, name := range test.missing {
+			if pkg.Scope().Lookup(name) != nil {
+				t.Errorf("%s, tests %v: %s is declared", test.goos, test.includeTests, name)
+			}
+		}
+	}
+}
+
+func TestForgetDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctxt := build.Default
+	ctxt.GOPATH = dir
+	overlay := map[string][]byte{
+		filepath.Join(dir, "src", "a", "a.go"): []byte("package a\n\nimport \"b\"\n\nvar A = b.B\n"),
+		filepath.Join(dir, "src", "b", "b.go"): []byte("package b\n\nvar B int\n"),
+		filepath.Join(dir, "src", "c", "c.go"): []byte("package c\n\nvar C int\n"),
+	}
+	p := NewImporter(OverlayContext(&ctxt, overlay), token.NewFileSet(), nil, 0)
+	imported := make(map[string]*types.Package)
+	for _, path := range []string{"a", "b", "c"} {
+		if imported[path], err = p.ImportFrom(path, dir, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// b changes, a imports it, c is unrelated
+	overlay[filepath.Join(dir, "src", "b", "b.go")] = []byte("package b\n\nvar B string\n")
+	p.ForgetDirs([]string{filepath.Join(dir, "src", "b")})
+	for _, path := range []string{"a", "b", "c"} {
+		pkg, err := p.ImportFrom(path, dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reused := pkg == imported[path]; reused != (path == "c") {
+			t.Errorf("package %s reused: %v", path, reused)
+		}
+	}
+	b, _ := p.ImportFrom("b", dir, 0)
+	if typ := b.Scope().Lookup("B").Type().String(); typ != "string" {
+		t.Errorf("B has type %s after the change", typ)
+	}
+}
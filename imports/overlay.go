@@ -7,6 +7,8 @@ package imports
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go/build"
 	"io"
@@ -20,7 +22,9 @@ import (
 )
 
 // OverlayContext overlays a build.Context with additional files from
-// a map. Files in the map take precedence over other files.
+// a map. Files in the map take precedence over other files. A nil
+// content marks a file as deleted, while an empty non-nil content is an
+// empty file.
 //
 // In addition to plain string comparison, two file names are
 // considered equal if their base names match and their directory
@@ -30,31 +34,39 @@ import (
 // A common use case for OverlayContext is to allow editors to pass in
 // a set of unsaved, modified files.
 //
-// Currently, only the Context.OpenFile function will respect the
-// overlay. This may change in the future.
+// The overlay is respected by the Context.OpenFile, IsDir, HasSubdir and
+// ReadDir functions, so files that only exist in the overlay, possibly in
+// new directories, take part in package discovery, and deleted files
+// don't.
 func OverlayContext(orig *build.Context, overlay map[string][]byte) *build.Context {
-	// TODO(dominikh): Implement IsDir, HasSubdir and ReadDir
-
 	rc := func(data []byte) (io.ReadCloser, error) {
 		return ioutil.NopCloser(bytes.NewBuffer(data)), nil
 	}
-
-	copy := *orig // make a copy
-	ctxt := &copy
-	ctxt.OpenFile = func(path string) (io.ReadCloser, error) {
+	lookup := func(path string) (content []byte, ok bool) {
 		// Fast path: names match exactly.
 		if content, ok := overlay[path]; ok {
-			return rc(content)
+			return content, true
 		}
 
 		// Slow path: check for same file under a different
 		// alias, perhaps due to a symbolic link.
 		for filename, content := range overlay {
 			if SameFile(path, filename) {
-				return rc(content)
+				return content, true
 			}
 		}
+		return nil, false
+	}
 
+	copy := *orig // make a copy
+	ctxt := &copy
+	ctxt.OpenFile = func(path string) (io.ReadCloser, error) {
+		if content, ok := lookup(path); ok {
+			if content == nil {
+				return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+			}
+			return rc(content)
+		}
 		return OpenFile(orig, path)
 	}
 	ctxt.IsDir = func(path string) bool {
@@ -62,8 +74,12 @@ func OverlayContext(orig *build.Context, overlay map[string][]byte) *build.Conte
 			return true
 		}
 
-		for filename, _ := range overlay {
-			if _, ok := hasSubdir(path, filepath.Dir(filename)); ok {
+		for filename, content := range overlay {
+			if content == nil {
+				continue
+			}
+			dir := filepath.Dir(filename)
+			if _, ok := hasSubdir(path, dir); ok || dir == filepath.Clean(path) {
 				return true
 			}
 		}
@@ -73,18 +89,25 @@ func OverlayContext(orig *build.Context, overlay map[string][]byte) *build.Conte
 		if rel, ok = HasSubdir(orig, root, dir); ok {
 			return
 		}
-		return "", false
+		// The directory may only exist in the overlay, so that
+		// symbolic links can't be evaluated.
+		return hasSubdir(root, dir)
 	}
 	ctxt.ReadDir = func(dir string) (fis []os.FileInfo, err error) {
 		fis1, err := ReadDir(orig, dir)
-		if err != nil {
-			return
+		if err != nil && !ctxt.IsDir(dir) {
+			return nil, err
 		}
 		fis2 := []os.FileInfo{}
+		deleted := make(map[string]bool)
 		for filename, bytes := range overlay {
 			if rel, ok := hasSubdir(dir, filename); ok {
-				idx := strings.IndexRune(rel, filepath.Separator)
-				if idx < 0 { // file
+				idx := strings.IndexRune(rel, '/')
+				if bytes == nil {
+					if idx < 0 {
+						deleted[rel] = true
+					}
+				} else if idx < 0 { // file
 					fis2 = append(fis2, &fileinfo{
 						name: rel,
 						size: int64(len(bytes)),
@@ -100,23 +123,24 @@ func OverlayContext(orig *build.Context, overlay map[string][]byte) *build.Conte
 				}
 			}
 		}
-		if len(fis2) > 0 {
-			m := make(map[string]bool)
-			for _, fi := range fis2 {
+		if len(fis2) == 0 && len(deleted) == 0 {
+			return fis1, nil
+		}
+
+		m := make(map[string]bool)
+		for _, fi := range fis2 {
+			if !m[fi.Name()] {
 				m[fi.Name()] = true
 				fis = append(fis, fi)
 			}
-			for _, fi := range fis1 {
-				if !m[fi.Name()] {
-					fis = append(fis, fi)
-				}
+		}
+		for _, fi := range fis1 {
+			if !m[fi.Name()] && !deleted[fi.Name()] {
+				fis = append(fis, fi)
 			}
-			sort.Slice(fis, func(i int, j int) bool { return fis[i].Name() < fis[j].Name() })
-		} else {
-			return fis1, nil
 		}
-		
-		return
+		sort.Slice(fis, func(i int, j int) bool { return fis[i].Name() < fis[j].Name() })
+		return fis, nil
 	}
 	return ctxt
 }
@@ -161,27 +185,42 @@ func (fi *fileinfo) Sys() interface{} {
 //
 // The archive consists of a series of files. Each file consists of a
 // name, a decimal file size and the file contents, separated by
-// newlinews. No newline follows after the file contents.
+// newlinews. No newline follows after the file contents. A size of -1
+// marks the file as deleted, and no contents follow. The size is in bytes
+// and the contents are kept exactly, carriage returns included.
 func ParseOverlayArchive(archive io.Reader) (map[string][]byte, error) {
 	overlay := make(map[string][]byte)
 	r := bufio.NewReader(archive)
 	for {
 		// Read file name.
 		filename, err := r.ReadString('\n')
-		if err != nil {
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading archive file name: %v", err)
+		}
+		if strings.TrimSpace(filename) == "" {
 			if err == io.EOF {
 				break // OK
 			}
-			return nil, fmt.Errorf("reading archive file name: %v", err)
+			return nil, errors.New("empty file name in archive")
 		}
 		filename = filepath.Clean(strings.TrimSpace(filename))
+		if err == io.EOF {
+			return nil, fmt.Errorf("archive file %s is truncated: missing size", filename)
+		}
 
 		// Read file size.
 		sz, err := r.ReadString('\n')
-		if err != nil {
+		if err != nil && err != io.EOF {
 			return nil, fmt.Errorf("reading size of archive file %s: %v", filename, err)
 		}
 		sz = strings.TrimSpace(sz)
+		if sz == "" {
+			return nil, fmt.Errorf("archive file %s is truncated: missing size", filename)
+		}
+		if sz == "-1" {
+			overlay[filename] = nil
+			continue
+		}
 		size, err := strconv.ParseUint(sz, 10, 32)
 		if err != nil {
 			return nil, fmt.Errorf("parsing size of archive file %s: %v", filename, err)
@@ -189,32 +228,93 @@ func ParseOverlayArchive(archive io.Reader) (map[string][]byte, error) {
 
 		// Read file content.
 		content := make([]byte, size)
-		i := 0
-		for size > 0 {
-			b, err := r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("reading archive file %s: %v", filename, err)
-			}
-			if b != '\r' {
-				content[i] = b
-				i++
+		if n, err := io.ReadFull(r, content); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("archive file %s is truncated: got %d of %d bytes", filename, n, size)
 			}
-			size--
+			return nil, fmt.Errorf("reading archive file %s: %v", filename, err)
 		}
-		overlay[filename] = content[:i]
+		overlay[filename] = content
 	}
 
 	return overlay, nil
 }
 
-func stripCR(b []byte) []byte {
-	c := make([]byte, len(b))
+// NormalizeNewlines returns content with its CRLF line endings replaced
+// by LF, and content itself if it has none.
+func NormalizeNewlines(content []byte) []byte {
+	if !bytes.Contains(content, []byte("\r\n")) {
+		return content
+	}
+	return bytes.Replace(content, []byte("\r\n"), []byte("\n"), -1)
+}
+
+// NormalizedOffset maps the byte offset in content to the corresponding
+// offset in NormalizeNewlines(content). An offset at the carriage return
+// of a CRLF is mapped to the newline.
+func NormalizedOffset(content []byte, offset int) int {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	n := offset
+	for i := 0; i < offset; i++ {
+		if content[i] == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+			n--
+		}
+	}
+	return n
+}
+
+// OriginalOffset is the inverse of NormalizedOffset: it maps the byte
+// offset in NormalizeNewlines(content) to the offset in content.
+func OriginalOffset(content []byte, offset int) int {
 	i := 0
-	for _, ch := range b {
-		if ch != '\r' {
-			c[i] = ch
+	for n := 0; n < offset && i < len(content); n++ {
+		if content[i] == '\r' && i+1 < len(content) && content[i+1] == '\n' {
 			i++
 		}
+		i++
+	}
+	return i
+}
+
+// ParseOverlayJSON parses an overlay in the JSON format of the go
+// command's -overlay flag, which maps file paths to the paths of their
+// replacements, an empty replacement deleting the file:
+//
+//	{"Replace": {"/src/a/a.go": "/tmp/a.go", "/src/a/gone.go": ""}}
+//
+// Relative paths are relative to the current directory. The replacements
+// are read immediately. The result is intended to be used with
+// OverlayContext.
+func ParseOverlayJSON(r io.Reader) (map[string][]byte, error) {
+	var spec struct {
+		Replace map[string]string
+	}
+	if err := json.NewDecoder(r).Decode(&spec); err != nil {
+		return nil, fmt.Errorf("parsing overlay JSON: %v", err)
+	}
+	overlay := make(map[string][]byte)
+	for from, to := range spec.Replace {
+		if from == "" {
+			return nil, errors.New("empty file name in overlay JSON")
+		}
+		filename, err := filepath.Abs(from)
+		if err != nil {
+			return nil, err
+		}
+		if to == "" {
+			overlay[filename] = nil
+			continue
+		}
+		content, err := ioutil.ReadFile(to)
+		if err != nil {
+			return nil, fmt.Errorf("reading overlay replacement for %s: %v", from, err)
+		}
+		if content == nil {
+			content = []byte{} // not deleted
+		}
+		overlay[filename] = content
 	}
-	return c[:i]
+	return overlay, nil
 }
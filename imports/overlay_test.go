@@ -0,0 +1,122 @@
+package imports
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOverlayNewAndDeletedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.go", "gone.go"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("package a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := dir + "/new.go\n10\npackage a\n" +
+		dir + "/gone.go\n-1\n" +
+		dir + "/sub/b.go\n10\npackage b\n"
+	overlay, err := ParseOverlayArchive(strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctxt := OverlayContext(&build.Default, overlay)
+
+	fis, err := ReadDir(ctxt, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	if got, want := strings.Join(names, " "), "a.go new.go sub"; got != want {
+		t.Errorf("ReadDir(%s) = %s, want %s", dir, got, want)
+	}
+
+	if _, err := OpenFile(ctxt, filepath.Join(dir, "gone.go")); err == nil {
+		t.Errorf("deleted file can be opened")
+	}
+	sub := filepath.Join(dir, "sub")
+	if !IsDir(ctxt, sub) {
+		t.Errorf("IsDir(%s) = false, want true", sub)
+	}
+	if fis, err := ReadDir(ctxt, sub); err != nil || len(fis) != 1 || fis[0].Name() != "b.go" {
+		t.Errorf("ReadDir(%s) = %v, %v, want b.go", sub, fis, err)
+	}
+}
+
+func TestParseOverlayJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	repl := filepath.Join(dir, "repl.go")
+	if err := ioutil.WriteFile(repl, []byte("package a\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := `{"Replace": {"/src/a/a.go": "` + filepath.ToSlash(repl) + `", "/src/a/gone.go": ""}}`
+	overlay, err := ParseOverlayJSON(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := filepath.Abs("/src/a/a.go")
+	if got := string(overlay[a]); got != "package a\r\n" {
+		t.Errorf("content of a.go = %q, want %q", got, "package a\r\n")
+	}
+	gone, _ := filepath.Abs("/src/a/gone.go")
+	if content, ok := overlay[gone]; !ok || content != nil {
+		t.Errorf("gone.go is not deleted")
+	}
+}
+
+func TestParseOverlayArchiveExact(t *testing.T) {
+	content := "package a\r\n\r\nconst s = `a\r\nb`\r\n"
+	archive := "/src/a/a.go\n" + strconv.Itoa(len(content)) + "\n" + content
+	overlay, err := ParseOverlayArchive(strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(overlay[filepath.Clean("/src/a/a.go")]); got != content {
+		t.Errorf("content = %q, want %q", got, content)
+	}
+
+	for _, archive := range []string{
+		"/src/a/a.go",
+		"/src/a/a.go\n",
+		"/src/a/a.go\n20\npackage a\n",
+	} {
+		if _, err := ParseOverlayArchive(strings.NewReader(archive)); err == nil || !strings.Contains(err.Error(), "truncated") {
+			t.Errorf("ParseOverlayArchive(%q) = %v, want truncated archive error", archive, err)
+		}
+	}
+}
+
+func TestNormalizedOffset(t *testing.T) {
+	content := []byte("a\r\nbc\r\n\rd")
+	normalized := NormalizeNewlines(content)
+	if got, want := string(normalized), "a\nbc\n\rd"; got != want {
+		t.Fatalf("NormalizeNewlines = %q, want %q", got, want)
+	}
+	for offset, want := range []int{0, 1, 1, 2, 3, 4, 4, 5, 6, 7} {
+		if got := NormalizedOffset(content, offset); got != want {
+			t.Errorf("NormalizedOffset(%d) = %d, want %d", offset, got, want)
+		}
+	}
+	for offset := range normalized {
+		if got := NormalizedOffset(content, OriginalOffset(content, offset)); got != offset {
+			t.Errorf("NormalizedOffset(OriginalOffset(%d)) = %d", offset, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/imports"
+	"github.com/JohnWall2016/gogetdef/lookup"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// parsePos parses a position given with -pos: either a byte offset, as in
+// foo.go:#123, or a line and column, as in foo.go:12:5.
+func parsePos(p string) (pos lookup.Pos, err error) {
+	if p == "" {
+		err = errors.New("missing required -pos flag")
+		return
+	}
+	invalid := fmt.Errorf("invalid option: -pos=%s", p)
+	sep := strings.LastIndex(p, ":")
+	if sep == -1 || sep == len(p)-1 {
+		return pos, invalid
+	}
+	if p[sep+1] == '#' {
+		// foo.go:#123
+		offset, err := strconv.ParseInt(p[sep+2:], 10, 32)
+		if err != nil {
+			return pos, invalid
+		}
+		return lookup.Pos{File: p[:sep], Offset: int(offset)}, nil
+	}
+
+	// foo.go:12:5
+	col, err := strconv.Atoi(p[sep+1:])
+	lsep := strings.LastIndex(p[:sep], ":")
+	if err != nil || lsep == -1 {
+		return pos, invalid
+	}
+	line, err := strconv.Atoi(p[lsep+1 : sep])
+	if err != nil || line < 1 || col < 1 {
+		return pos, invalid
+	}
+	return lookup.Pos{File: p[:lsep], Offset: -1, Line: line, Col: col}, nil
+}
+
+// readOverlay reads the -overlay file and parses the archive of modified
+// files, if any. Files in the archive take precedence.
+func readOverlay(archive io.Reader) (overlay map[string][]byte, err error) {
+	if *overlayFile != "" {
+		f, err := os.Open(*overlayFile)
+		if err != nil {
+			return nil, err
+		}
+		overlay, err = imports.ParseOverlayJSON(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if archive != nil {
+		modified, err := imports.ParseOverlayArchive(archive)
+		if err != nil {
+			return nil, err
+		}
+		if overlay == nil {
+			return modified, nil
+		}
+		for filename, content := range modified {
+			overlay[filename] = content
+		}
+	}
+	return
+}
+
+// splitTags splits a -tags value, which may be comma or space separated.
+func splitTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package lookup
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/imports"
+	"github.com/JohnWall2016/gogetdef/types"
+	"path/filepath"
+	"strings"
+)
+
+// asmImpls returns the TEXT symbols implementing the body-less function fn
+// in the assembly files of its package.
+func (ti *typeInfo) asmImpls(fn *types.Func) (impls []*typePos) {
+	p := ti.fset.Position(fn.Pos())
+	if !p.IsValid() {
+		return nil
+	}
+	dir := filepath.Dir(p.Filename)
+	bp, err := ti.ctxt.ImportDir(dir, 0)
+	if err != nil && bp == nil {
+		return nil
+	}
+
+	names := asmNames(fn)
+	for _, sfile := range bp.SFiles {
+		fileName := imports.JoinPath(ti.ctxt, dir, sfile)
+		rc, err := imports.OpenFile(ti.ctxt, fileName)
+		if err != nil {
+			continue
+		}
+		s := bufio.NewScanner(rc)
+		for line := 1; s.Scan(); line++ {
+			text := s.Text()
+			col := strings.Index(text, "TEXT")
+			if col < 0 || strings.TrimSpace(text[:col]) != "" {
+				continue
+			}
+			if sym, ok := asmTextSymbol(text[col+len("TEXT"):]); ok && asmMatch(sym, fn.Pkg(), names) {
+				impls = append(impls, &typePos{
+					typ: strings.TrimSpace(text),
+					pos: fmt.Sprintf("%s:%d:%d", fileName, line, col+1),
+				})
+			}
+		}
+		rc.Close()
+	}
+	return
+}
+
+// asmNames returns the forms in which the assembly symbol of fn may be
+// written, without the package qualifier: Name for functions, T.Name and
+// (*T).Name for methods.
+func asmNames(fn *types.Func) []string {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return []string{fn.Name()}
+	}
+	recv := sig.Recv().Type()
+	ptr := false
+	if p, ok := recv.(*types.Pointer); ok {
+		recv, ptr = p.Elem(), true
+	}
+	named, ok := recv.(*types.Named)
+	if !ok {
+		return nil
+	}
+	tname := named.Obj().Name()
+	if ptr {
+		return []string{"(*" + tname + ")." + fn.Name()}
+	}
+	return []string{tname + "." + fn.Name(), "(*" + tname + ")." + fn.Name()}
+}
+
+// asmTextSymbol returns the symbol of the TEXT directive with the given
+// operands, e.g. "·Sqrt" for " ·Sqrt(SB),NOSPLIT,$0".
+func asmTextSymbol(operands string) (string, bool) {
+	operands = strings.TrimSpace(operands)
+	i := strings.Index(operands, "(SB)")
+	if i <= 0 {
+		return "", false
+	}
+	sym := operands[:i]
+	// strip an ABI selector like <ABIInternal>
+	if j := strings.Index(sym, "<"); j >= 0 {
+		sym = sym[:j]
+	}
+	return sym, true
+}
+
+// asmMatch reports whether the assembly symbol sym denotes one of names
+// in package pkg. The symbol is either local, as in ·Name, or qualified
+// with the package path, with slashes written as division slashes.
+func asmMatch(sym string, pkg *types.Package, names []string) bool {
+	i := strings.Index(sym, "·")
+	if i < 0 {
+		return false
+	}
+	qual, name := sym[:i], sym[i+len("·"):]
+	if qual != "" && pkg != nil {
+		path := strings.Replace(qual, "∕", "/", -1)
+		if path != pkg.Path() && path != pkg.Name() {
+			return false
+		}
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package lookup
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAsmImpls(t *testing.T) {
+	dir := filepath.Join(getTestDataDir(), "asm")
+	fileName := filepath.Join(dir, "asm.go")
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := "return f() + g(1)"
+	base := strings.Index(string(content), src)
+	tests := []struct {
+		at, impl string
+		line     int
+	}{
+		{"f()", "TEXT ·f(SB),NOSPLIT,$0-8", 3},
+		{"g(1)", "TEXT asm·g(SB),NOSPLIT,$0-16", 6},
+	}
+	s := NewSession(Options{})
+	for _, test := range tests {
+		def, err := s.Definition(context.Background(), fileName, base+strings.Index(src, test.at), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.at, err)
+		}
+		want := Related{test.impl, filepath.Join(dir, "asm.s") + ":" + strconv.Itoa(test.line) + ":1"}
+		if len(def.Impls) != 1 || def.Impls[0] != want {
+			t.Errorf("%s: got %v, want %v", test.at, def.Impls, want)
+		}
+	}
+}
+
+func TestAsmTextSymbol(t *testing.T) {
+	tests := []struct {
+		operands, sym string
+		ok            bool
+	}{
+		{" ·Sqrt(SB),NOSPLIT,$0", "·Sqrt", true},
+		{"\tmath·Sqrt(SB), NOSPLIT, $0", "math·Sqrt", true},
+		{" runtime∕internal∕atomic·Load<ABIInternal>(SB),NOSPLIT,$0", "runtime∕internal∕atomic·Load", true},
+		{" (SB)", "", false},
+		{" foo", "", false},
+	}
+	for _, test := range tests {
+		sym, ok := asmTextSymbol(test.operands)
+		if sym != test.sym || ok != test.ok {
+			t.Errorf("asmTextSymbol(%q) = %q, %v, want %q, %v", test.operands, sym, ok, test.sym, test.ok)
+		}
+	}
+}
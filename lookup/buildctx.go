@@ -0,0 +1,199 @@
+package lookup
+
+import (
+	"github.com/JohnWall2016/gogetdef/imports"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+var knownOS = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js",
+	"linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos",
+}
+
+var knownArch = []string{
+	"386", "amd64", "arm", "arm64", "loong64", "mips", "mipsle", "mips64", "mips64le",
+	"ppc64", "ppc64le", "riscv64", "s390x", "wasm",
+}
+
+// maxAutoTags limits the number of tags tried when searching for a build
+// configuration that includes a file.
+const maxAutoTags = 6
+
+// derivedTags are satisfied or not by the build context itself, so they
+// are never set as build tags.
+var derivedTags = []string{"cgo", "unix", "gc", "gccgo"}
+
+// selectContext returns a copy of the build context in which fileName is
+// part of the package being built. GOOS, GOARCH and tags set in the
+// options are kept; the others are picked from the file name and build
+// constraints, trying only the values mentioned by them. Every selection
+// starts from ti.ctxt, the context of the options, which is left
+// unchanged.
+func (ti *typeInfo) selectContext(fileName string) *build.Context {
+	selected := *ti.ctxt
+	rc, err := imports.OpenFile(ti.ctxt, fileName)
+	if err != nil {
+		return &selected
+	}
+	src, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return &selected
+	}
+	x, err := imports.ReadConstraint(src)
+	if err != nil {
+		return &selected
+	}
+
+	dir, name := filepath.Split(fileName)
+	ctxt := *ti.ctxt
+	// the file name alone is judged by go/build, the constraint by x
+	ctxt.OpenFile = func(path string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("package p\n")), nil
+	}
+	match := func() bool {
+		if ok, err := ctxt.MatchFile(dir, name); err != nil || !ok {
+			return false
+		}
+		return x == nil || x.Eval(func(tag string) bool { return imports.MatchTag(&ctxt, tag) })
+	}
+	if match() {
+		return &selected
+	}
+
+	var mentioned, extra []string
+	if x != nil {
+		mentioned = imports.ConstraintTags(x)
+	}
+	for _, tag := range mentioned {
+		if !contains(knownOS, tag) && !contains(knownArch, tag) && !contains(derivedTags, tag) &&
+			!contains(ctxt.BuildTags, tag) && !contains(ctxt.ToolTags, tag) {
+			extra = append(extra, tag)
+		}
+	}
+	if len(extra) > maxAutoTags {
+		extra = extra[:maxAutoTags]
+	}
+
+	nameOS, nameArch := osArchOfName(name)
+	oses := []string{ctxt.GOOS}
+	if ti.opts.GOOS == "" {
+		candidates := append([]string{nameOS}, mentioned...)
+		if contains(mentioned, "unix") {
+			// a unix and a non-unix system, to satisfy unix or !unix
+			candidates = append(candidates, "linux", "windows")
+		}
+		oses = appendKnown(oses, candidates, knownOS)
+	}
+	arches := []string{ctxt.GOARCH}
+	if ti.opts.GOARCH == "" {
+		arches = appendKnown(arches, append([]string{nameArch}, mentioned...), knownArch)
+	}
+	tags := ctxt.BuildTags
+	subsets := [][]string{nil}
+	if len(ti.opts.Tags) == 0 {
+		subsets = tagSubsets(extra)
+	}
+
+	for _, osName := range oses {
+		for _, archName := range arches {
+			for _, subset := range subsets {
+				ctxt.GOOS, ctxt.GOARCH = osName, archName
+				ctxt.BuildTags = append(tags[:len(tags):len(tags)], subset...)
+				if match() {
+					selected.GOOS, selected.GOARCH, selected.BuildTags = ctxt.GOOS, ctxt.GOARCH, ctxt.BuildTags
+					return &selected
+				}
+			}
+		}
+	}
+	return &selected
+}
+
+// appendKnown appends the candidates in known to list, skipping those
+// already in it.
+func appendKnown(list, candidates, known []string) []string {
+	for _, c := range candidates {
+		if contains(known, c) && !contains(list, c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// osArchOfName returns the GOOS and GOARCH required by the _GOOS_GOARCH
+// suffixes of the file name, if any, following the rules of go/build.
+func osArchOfName(name string) (osName, archName string) {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return
+	}
+	l := strings.Split(strings.TrimSuffix(name[i:], "_test"), "_")
+	n := len(l)
+	switch {
+	case n >= 2 && contains(knownOS, l[n-2]) && contains(knownArch, l[n-1]):
+		return l[n-2], l[n-1]
+	case n >= 1 && contains(knownOS, l[n-1]):
+		return l[n-1], ""
+	case n >= 1 && contains(knownArch, l[n-1]):
+		return "", l[n-1]
+	}
+	return
+}
+
+// tagSubsets returns all subsets of tags, smallest first.
+func tagSubsets(tags []string) [][]string {
+	subsets := [][]string{nil}
+	for size := 1; size <= len(tags); size++ {
+		for mask := 1; mask < 1<<uint(len(tags)); mask++ {
+			if bitCount(mask) != size {
+				continue
+			}
+			var subset []string
+			for i, tag := range tags {
+				if mask&(1<<uint(i)) != 0 {
+					subset = append(subset, tag)
+				}
+			}
+			subsets = append(subsets, subset)
+		}
+	}
+	return subsets
+}
+
+func bitCount(n int) (c int) {
+	for ; n != 0; n &= n - 1 {
+		c++
+	}
+	return
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// newContext returns the build context selected by the GOOS, GOARCH and
+// Tags options.
+func newContext(opts *Options) *build.Context {
+	ctxt := build.Default
+	if opts.GOOS != "" {
+		ctxt.GOOS = opts.GOOS
+	}
+	if opts.GOARCH != "" {
+		ctxt.GOARCH = opts.GOARCH
+	}
+	if len(opts.Tags) > 0 {
+		ctxt.BuildTags = opts.Tags
+	}
+	return &ctxt
+}
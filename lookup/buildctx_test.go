@@ -0,0 +1,65 @@
+package lookup
+
+import (
+	"go/build"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelectContext(t *testing.T) {
+	dir := filepath.Join(getTestDataDir(), "buildctx")
+	tests := []struct {
+		name, src  string
+		goos, arch string
+		tags       string
+	}{
+		{"plain.go", "package p\n", "linux", "amd64", ""},
+		{"a_darwin_arm64.go", "package p\n", "darwin", "arm64", ""},
+		{"b_windows.go", "package p\n", "windows", "amd64", ""},
+		{"c.go", "//go:build plan9 && foo\n\npackage p\n", "plan9", "amd64", "foo"},
+		{"d.go", "//go:build (darwin || plan9) && !unix\n\npackage p\n", "plan9", "amd64", ""},
+		// only the systems mentioned are tried
+		{"e.go", "//go:build !unix && !windows && bar\n\npackage p\n", "linux", "amd64", ""},
+		{"f.go", "//go:build riscv64 || (cgo && baz)\n\npackage p\n", "linux", "riscv64", ""},
+		{"g.go", "// +build ignore\n\npackage p\n", "linux", "amd64", "ignore"},
+	}
+	for _, test := range tests {
+		fileName := filepath.Join(dir, test.name)
+		overlay := map[string][]byte{fileName: []byte(test.src)}
+		ti := newTypeInfo(&Options{}, overlay)
+		ti.ctxt.GOOS, ti.ctxt.GOARCH, ti.ctxt.BuildTags = "linux", "amd64", nil
+		ti.ctxt.CgoEnabled = false
+		ctxt := ti.selectContext(fileName)
+		got := ctxt.GOOS + "/" + ctxt.GOARCH + " " + strings.Join(ctxt.BuildTags, ",")
+		want := test.goos + "/" + test.arch + " " + test.tags
+		if got != want {
+			t.Errorf("%s: got %s, want %s", test.name, got, want)
+		}
+	}
+}
+
+func TestSelectContextPerLookup(t *testing.T) {
+	tree := newTestTree(t, map[string]string{
+		"x/f_plan9.go": "package x\n\nfunc F() {}\n",
+		"x/f_other.go": "//go:build !plan9\n\npackage x\n\nfunc F() {}\n",
+		"x/w_plan9.go": "package x\n\nvar _ = F\n",
+		"x/x.go":       "package x\n\nvar _ = F\n",
+	})
+	defer tree.close()
+	s := NewSession(Options{})
+	// the context selected for w_plan9.go is not kept for x.go
+	for _, test := range []struct{ from, to string }{
+		{"x/w_plan9.go", "x/f_plan9.go"},
+		{"x/x.go", "x/f_other.go"},
+		{"x/w_plan9.go", "x/f_plan9.go"},
+	} {
+		def := tree.definition(t, s, test.from, "F", 0)
+		if want := tree.path(test.to); !strings.HasPrefix(def.Pos, want+":") {
+			t.Errorf("from %s: got %s, want %s", test.from, def.Pos, want)
+		}
+	}
+	if s.ti.ctxt.GOOS != build.Default.GOOS {
+		t.Errorf("the context of the session was changed to %s", s.ti.ctxt.GOOS)
+	}
+}
@@ -0,0 +1,137 @@
+package lookup
+
+import (
+	"errors"
+	"github.com/JohnWall2016/gogetdef/imports"
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"go/build"
+	goparser "go/parser"
+	"io/ioutil"
+	"strings"
+)
+
+// builtinIdent describes a predeclared object or a member of package
+// unsafe by its entry in the documentation packages builtin and unsafe.
+// Their sources are parsed with the standard parser, as they use
+// language features the type checker does not know about.
+func (ti *typeInfo) builtinIdent(obj types.Object) (dcl *declaration, err error) {
+	path := "builtin"
+	if obj.Pkg() != nil {
+		path = obj.Pkg().Path()
+	}
+	bp, err := ti.ctxt.Import(path, "", build.FindOnly)
+	if err != nil {
+		return
+	}
+	list, err := imports.ReadDir(ti.ctxt, bp.Dir)
+	if err != nil {
+		return
+	}
+
+	name := obj.Name()
+	for _, fi := range list {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".go") || strings.HasSuffix(fi.Name(), "_test.go") {
+			continue
+		}
+		fileName := imports.JoinPath(ti.ctxt, bp.Dir, fi.Name())
+		rc, err := imports.OpenFile(ti.ctxt, fileName)
+		if err != nil {
+			continue
+		}
+		src, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		file, err := goparser.ParseFile(ti.fset, fileName, src, goparser.ParseComments)
+		if file == nil {
+			continue
+		}
+		if id, node, doc := findTopLevelDecl(file, name); node != nil {
+			dcl = &declaration{name: name}
+			dcl.pos = ti.fset.Position(id.Pos()).String()
+			describeNode(dcl, node)
+			// all predeclared names are lower case, never trim them
+			dcl.typ = formatNode(node, obj, ti.fset, true)
+			if ti.opts.All {
+				dcl.imprt, dcl.pkg = path, file.Name.Name
+				dcl.doc = doc.Text()
+			}
+			return dcl, nil
+		}
+	}
+	return nil, errors.New("can't find the declaration of " + name + " in package " + path)
+}
+
+// findTopLevelDecl returns the identifier, the node to print and the
+// documentation of the top-level declaration of name in file. A name of
+// the form T.m or (*T).m, as in linkname targets, denotes a method.
+func findTopLevelDecl(file *ast.File, name string) (*ast.Ident, ast.Node, *ast.CommentGroup) {
+	recv, method := splitMethodName(name)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && recv == "" && d.Name.Name == name {
+				return d.Name, d, d.Doc
+			}
+			if d.Recv != nil && recv != "" && d.Name.Name == method && recvTypeName(d.Recv) == recv {
+				return d.Name, d, d.Doc
+			}
+		case *ast.GenDecl:
+			if recv != "" {
+				continue
+			}
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.Name == name {
+						doc := s.Doc
+						if doc == nil {
+							doc = d.Doc
+						}
+						return s.Name, s, doc
+					}
+				case *ast.ValueSpec:
+					for _, id := range s.Names {
+						if id.Name == name {
+							doc := s.Doc
+							if doc == nil {
+								doc = d.Doc
+							}
+							// print this spec only
+							return id, &ast.GenDecl{Tok: d.Tok, Specs: []ast.Spec{s}}, doc
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// splitMethodName splits a method name qualified by its receiver type, as
+// in T.m or (*T).m, into the type name and the method name.
+func splitMethodName(name string) (recv, method string) {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return "", name
+	}
+	recv = strings.TrimSuffix(strings.TrimPrefix(name[:i], "(*"), ")")
+	return recv, name[i+1:]
+}
+
+// recvTypeName returns the name of the base type of the receiver.
+func recvTypeName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	typ := recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
@@ -0,0 +1,352 @@
+package lookup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/imports"
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth limits how deep #include directives are followed.
+const maxIncludeDepth = 8
+
+// A cLine is a line of C source, the cgo preamble or a header.
+type cLine struct {
+	text      string
+	file      string
+	line, col int // position of the start of text
+}
+
+// isCgoRef reports whether id is the name selected in a C.name expression.
+func (ti *typeInfo) isCgoRef(id *ast.Ident, path []ast.Node) bool {
+	if len(path) < 2 {
+		return false
+	}
+	sel, ok := path[1].(*ast.SelectorExpr)
+	if !ok || sel.Sel != id {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pkg, ok := ti.Uses[x].(*types.PkgName)
+	return ok && pkg.Imported().Path() == "C"
+}
+
+// cgoHelpers are the functions provided by cgo itself, with their
+// documentation from cmd/cgo.
+var cgoHelpers = map[string][2]string{
+	"CString": {"func C.CString(string) *C.char",
+		"CString converts a Go string to a C string, allocated in the C heap using malloc. It is the caller's responsibility to free it.\n"},
+	"CBytes": {"func C.CBytes([]byte) unsafe.Pointer",
+		"CBytes converts a Go []byte slice to a C array, allocated in the C heap using malloc. It is the caller's responsibility to free it.\n"},
+	"GoString": {"func C.GoString(*C.char) string",
+		"GoString converts a C string to a Go string.\n"},
+	"GoStringN": {"func C.GoStringN(*C.char, C.int) string",
+		"GoStringN converts C data with explicit length to a Go string.\n"},
+	"GoBytes": {"func C.GoBytes(unsafe.Pointer, C.int) []byte",
+		"GoBytes converts C data with explicit length to a Go []byte.\n"},
+}
+
+// cgoHelper describes the cgo helper function name, if it is one. Its
+// position is the directory of cmd/cgo, which documents it.
+func (ti *typeInfo) cgoHelper(name string) *declaration {
+	h, ok := cgoHelpers[name]
+	if !ok {
+		return nil
+	}
+	dcl := &declaration{name: name, kind: KindFunc}
+	dcl.typ = h[0]
+	dcl.otype = strings.TrimPrefix(h[0], "func C."+name)
+	dcl.pos = filepath.Join(ti.ctxt.GOROOT, "src", "cmd", "cgo")
+	if ti.opts.All {
+		dcl.imprt, dcl.pkg = "C", "C"
+		dcl.doc = h[1]
+	}
+	return dcl
+}
+
+// cgoIdent looks for the C declaration of name, as in C.name, in the cgo
+// preamble of astFile and the headers it includes, searched for in the
+// directory of the file, the -I directories of #cgo lines and the system
+// include directories. The C sources are scanned textually only, so the
+// result is a best guess.
+func (ti *typeInfo) cgoIdent(fileName string, astFile *ast.File, name string) (*declaration, error) {
+	if dcl := ti.cgoHelper(name); dcl != nil {
+		return dcl, nil
+	}
+	preamble := ti.cgoPreamble(astFile)
+	if preamble == nil {
+		return nil, errors.New("can't find the cgo preamble")
+	}
+	dir := filepath.Dir(fileName)
+	incDirs := []string{dir}
+	for _, l := range preamble {
+		incDirs = append(incDirs, cgoIncludeDirs(l.text, dir)...)
+	}
+	incDirs = append(incDirs, ti.systemIncludeDirs()...)
+
+	seen := make(map[string]bool)
+	srcs := [][]cLine{preamble}
+	for depth := 0; len(srcs) > 0 && depth <= maxIncludeDepth; depth++ {
+		var next [][]cLine
+		for _, src := range srcs {
+			if dcl := cFind(src, name); dcl != nil {
+				if ti.opts.All {
+					dcl.imprt, dcl.pkg = "C", "C"
+				}
+				return dcl, nil
+			}
+			for _, l := range src {
+				header, quoted := cInclude(l.text)
+				if header == "" {
+					continue
+				}
+				dirs := incDirs[1:]
+				if quoted {
+					// quoted headers are first looked up next to the includer
+					dirs = append([]string{filepath.Dir(l.file)}, dirs...)
+				}
+				for _, d := range dirs {
+					fileName := filepath.Join(d, header)
+					if seen[fileName] {
+						break
+					}
+					if lines, err := ti.readCFile(fileName); err == nil {
+						seen[fileName] = true
+						next = append(next, lines)
+						break
+					}
+				}
+			}
+		}
+		srcs = next
+	}
+	return nil, fmt.Errorf("can't find the C declaration of %s", name)
+}
+
+// cgoPreamble returns the lines of the doc comment of import "C".
+func (ti *typeInfo) cgoPreamble(astFile *ast.File) (lines []cLine) {
+	for _, decl := range astFile.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ispec, ok := spec.(*ast.ImportSpec)
+			if !ok || ispec.Path.Value != `"C"` {
+				continue
+			}
+			cg := ispec.Doc
+			if cg == nil && gen.Lparen == 0 {
+				cg = gen.Doc
+			}
+			if cg == nil {
+				return nil
+			}
+			for _, c := range cg.List {
+				p := ti.fset.Position(c.Pos())
+				text := c.Text[2:]
+				if c.Text[1] == '*' {
+					text = strings.TrimSuffix(text, "*/")
+				}
+				for i, t := range strings.Split(text, "\n") {
+					l := cLine{text: t, file: p.Filename, line: p.Line + i, col: 1}
+					if i == 0 {
+						l.col = p.Column + 2
+					}
+					lines = append(lines, l)
+				}
+			}
+			return lines
+		}
+	}
+	return nil
+}
+
+func (ti *typeInfo) readCFile(fileName string) (lines []cLine, err error) {
+	rc, err := imports.OpenFile(ti.ctxt, fileName)
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	s := bufio.NewScanner(rc)
+	for n := 1; s.Scan(); n++ {
+		lines = append(lines, cLine{text: s.Text(), file: fileName, line: n, col: 1})
+	}
+	return lines, s.Err()
+}
+
+// cgoIncludeDirs returns the -I directories of a #cgo CFLAGS or CPPFLAGS
+// line, relative paths being relative to the package directory dir.
+func cgoIncludeDirs(line, dir string) (dirs []string) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "#cgo ") {
+		return nil
+	}
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return nil
+	}
+	verb := strings.Fields(line[:i])
+	if v := verb[len(verb)-1]; v != "CFLAGS" && v != "CPPFLAGS" {
+		return nil
+	}
+	args := strings.Fields(strings.Replace(line[i+1:], "${SRCDIR}", dir, -1))
+	for j := 0; j < len(args); j++ {
+		var d string
+		switch {
+		case args[j] == "-I" && j+1 < len(args):
+			j++
+			d = args[j]
+		case strings.HasPrefix(args[j], "-I"):
+			d = args[j][2:]
+		default:
+			continue
+		}
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(dir, d)
+		}
+		dirs = append(dirs, d)
+	}
+	return
+}
+
+// systemIncludeDirs returns the directories searched for headers by the C
+// compiler by default: those of the CPATH and C_INCLUDE_PATH variables and
+// the usual directories of Unix systems.
+func (ti *typeInfo) systemIncludeDirs() (dirs []string) {
+	for _, env := range []string{"CPATH", "C_INCLUDE_PATH"} {
+		for _, d := range filepath.SplitList(os.Getenv(env)) {
+			if d != "" {
+				dirs = append(dirs, d)
+			}
+		}
+	}
+	dirs = append(dirs, "/usr/local/include")
+	if triplet := multiarchTriplets[ti.ctxt.GOARCH]; triplet != "" && ti.ctxt.GOOS == "linux" {
+		dirs = append(dirs, "/usr/include/"+triplet)
+	}
+	return append(dirs, "/usr/include")
+}
+
+// multiarchTriplets are the names of the architecture dependent include
+// directories of Debian based systems.
+var multiarchTriplets = map[string]string{
+	"386":     "i386-linux-gnu",
+	"amd64":   "x86_64-linux-gnu",
+	"arm":     "arm-linux-gnueabihf",
+	"arm64":   "aarch64-linux-gnu",
+	"ppc64le": "powerpc64le-linux-gnu",
+	"riscv64": "riscv64-linux-gnu",
+	"s390x":   "s390x-linux-gnu",
+}
+
+var includeRe = regexp.MustCompile(`^\s*#\s*include\s*([<"])([^>"]+)[>"]`)
+
+// cInclude returns the header of an #include directive and whether
+// it is quoted rather than in angle brackets.
+func cInclude(line string) (header string, quoted bool) {
+	m := includeRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	return m[2], m[1] == `"`
+}
+
+// cNonTypes are the keywords that may start a line calling a function,
+// which must not be taken for the declaration of its result type.
+var cNonTypes = map[string]bool{
+	"return": true, "else": true, "case": true, "goto": true, "sizeof": true, "do": true,
+}
+
+// cFind looks for the declaration of the cgo name in src: a macro, a
+// typedef, a struct, union or enum tag (as in C.struct_name), a function
+// or a variable. Functions and variables are only recognised at the start
+// of a line, as top-level declarations are usually written.
+func cFind(src []cLine, name string) *declaration {
+	ident := name
+	tag := ""
+	for _, kind := range []string{"struct", "union", "enum"} {
+		if strings.HasPrefix(name, kind+"_") {
+			tag, ident = kind, name[len(kind)+1:]
+		}
+	}
+	q := regexp.QuoteMeta(ident)
+	type pattern struct {
+		re    *regexp.Regexp
+		group int    // of the name; a preceding group is a result type
+		term  string // characters ending the declaration
+	}
+	var patterns []pattern
+	if tag != "" {
+		patterns = []pattern{{regexp.MustCompile(`\b` + tag + `\s+(` + q + `)\b\s*(\{|$)`), 1, "{;"}}
+	} else {
+		patterns = []pattern{
+			{regexp.MustCompile(`^\s*#\s*define\s+(` + q + `)\b`), 1, ""},
+			{regexp.MustCompile(`\btypedef\b.*\b(` + q + `)\s*(;|\[)`), 1, ";"},
+			{regexp.MustCompile(`^\s*}\s*(` + q + `)\s*;`), 1, ";"},
+			{regexp.MustCompile(`^((?:[A-Za-z_]\w*[\s\*]+)+)(` + q + `)\s*\(`), 2, ";{"},
+			{regexp.MustCompile(`^((?:[A-Za-z_]\w*[\s\*]+)+)(` + q + `)\s*(=|;|\[|,)`), 2, ";"},
+		}
+	}
+
+	for _, p := range patterns {
+		for i, l := range src {
+			m := p.re.FindStringSubmatchIndex(l.text)
+			if m == nil {
+				continue
+			}
+			if p.group == 2 {
+				if f := strings.Fields(l.text[m[2]:m[3]]); len(f) > 0 && cNonTypes[f[0]] {
+					continue
+				}
+			}
+			dcl := &declaration{name: name}
+			dcl.pos = l.file + ":" + strconv.Itoa(l.line) + ":" + strconv.Itoa(l.col+m[2*p.group])
+			dcl.typ = cDeclText(src[i:], p.term)
+			return dcl
+		}
+	}
+	return nil
+}
+
+// cDeclText returns the declaration starting at the first line of src,
+// which extends to a character of term or, for macros, over the lines
+// continued with a backslash.
+func cDeclText(src []cLine, term string) string {
+	var text []string
+	for i, l := range src {
+		if i == 10 {
+			text = append(text, "...")
+			break
+		}
+		t := strings.TrimRight(l.text, " \t")
+		if term == "" {
+			text = append(text, t)
+			if !strings.HasSuffix(t, "\\") {
+				break
+			}
+			continue
+		}
+		if j := strings.IndexAny(t, term); j >= 0 {
+			if t[j] == '{' {
+				t = strings.TrimRight(t[:j], " \t")
+			} else {
+				t = t[:j+1]
+			}
+			text = append(text, t)
+			break
+		}
+		text = append(text, t)
+	}
+	return strings.TrimSpace(strings.Join(text, "\n"))
+}
@@ -0,0 +1,51 @@
+package lookup
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCgoIdent(t *testing.T) {
+	dir := filepath.Join(getTestDataDir(), "cgo")
+	fileName := filepath.Join(dir, "cgo.go")
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at, decl, pos string
+	}{
+		{"CString(", "func C.CString(string) *C.char", ""},
+		{"LIMIT)", "#define LIMIT 10", fileName + ":6:12"},
+		{"norm(", "int norm(struct point p, int limit);", filepath.Join(dir, "include", "point.h") + ":3:5"},
+		{"struct_point", "struct point", filepath.Join(dir, "include", "point.h") + ":1:8"},
+		{"puts(", "", "/usr/include/stdio.h:"},
+	}
+	if _, err := os.Stat("/usr/include/stdio.h"); err != nil {
+		tests = tests[:len(tests)-1]
+	}
+	s := NewSession(Options{All: true})
+	for _, test := range tests {
+		def, err := s.Definition(context.Background(), fileName, strings.Index(string(content), test.at), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.at, err)
+		}
+		if test.decl == "" {
+			// the system header varies, check only where it is
+			if !strings.Contains(def.Decl, "puts") || !strings.HasPrefix(def.Pos, test.pos) {
+				t.Errorf("%s: got %q at %s", test.at, def.Decl, def.Pos)
+			}
+			continue
+		}
+		if def.Decl != test.decl {
+			t.Errorf("%s: got decl %q, want %q", test.at, def.Decl, test.decl)
+		}
+		if test.pos != "" && def.Pos != test.pos {
+			t.Errorf("%s: got pos %s, want %s", test.at, def.Pos, test.pos)
+		}
+	}
+}
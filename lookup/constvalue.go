@@ -0,0 +1,124 @@
+package lookup
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"math/big"
+	"sort"
+	"text/tabwriter"
+)
+
+// constValue describes the value of a constant: its exact value, its
+// default type, the hexadecimal and binary forms of integers and, for
+// enum-like constants, the sibling constants of the same type.
+func (ti *typeInfo) constValue(c *types.Const, nodes []ast.Node) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Constant Value: %s\n", c.Val().ExactString())
+	// the type an untyped constant defaults to, or the underlying type
+	if b, ok := c.Type().(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		fmt.Fprintf(buf, "Default Type: %s\n", types.Default(c.Type()))
+	} else {
+		fmt.Fprintf(buf, "Default Type: %s\n", c.Type().Underlying())
+	}
+	if c.Val().Kind() == constant.Int {
+		if n, ok := new(big.Int).SetString(c.Val().ExactString(), 10); ok {
+			fmt.Fprintf(buf, "Hex: %s\n", intText(n, 16, "0x"))
+			fmt.Fprintf(buf, "Binary: %s\n", intText(n, 2, "0b"))
+		}
+	}
+	if sibs := ti.constSiblings(c, nodes); len(sibs) > 1 {
+		fmt.Fprintf(buf, "Constants of type %s:\n", c.Type())
+		w := tabwriter.NewWriter(buf, 0, 8, 1, ' ', 0)
+		for _, s := range sibs {
+			fmt.Fprintf(w, "    %s\t= %s\n", s.Name(), s.Val().ExactString())
+		}
+		w.Flush()
+	}
+	return buf.String()
+}
+
+func intText(n *big.Int, base int, prefix string) string {
+	if n.Sign() < 0 {
+		return "-" + prefix + new(big.Int).Neg(n).Text(base)
+	}
+	return prefix + n.Text(base)
+}
+
+type constsByValue []*types.Const
+
+func (p constsByValue) Len() int { return len(p) }
+func (p constsByValue) Less(i, j int) bool {
+	x, y := p[i].Val(), p[j].Val()
+	if x.Kind() == y.Kind() && x.Kind() != constant.Bool && x.Kind() != constant.Complex &&
+		!constant.Compare(x, token.EQL, y) {
+		return constant.Compare(x, token.LSS, y)
+	}
+	return p[i].Pos() < p[j].Pos()
+}
+func (p constsByValue) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+// constSiblings returns the constants forming an enum with c. If the type
+// of c is a named type with a String method, these are all package-level
+// constants of that type. Otherwise, if c is declared in an iota block,
+// they are the constants of the same type declared in that block.
+func (ti *typeInfo) constSiblings(c *types.Const, nodes []ast.Node) (sibs []*types.Const) {
+	named, ok := c.Type().(*types.Named)
+	if ok && c.Pkg() != nil && c.Parent() == c.Pkg().Scope() {
+		if m, _, _ := types.LookupFieldOrMethod(named, false, nil, "String"); m != nil {
+			scope := c.Pkg().Scope()
+			for _, name := range scope.Names() {
+				if s, ok := scope.Lookup(name).(*types.Const); ok && types.Identical(s.Type(), c.Type()) {
+					sibs = append(sibs, s)
+				}
+			}
+			sort.Sort(constsByValue(sibs))
+			return
+		}
+	}
+
+	var decl *ast.GenDecl
+	for _, node := range nodes {
+		if n, ok := node.(*ast.GenDecl); ok {
+			decl = n
+			break
+		}
+	}
+	if decl == nil || !usesIota(decl) {
+		return nil
+	}
+	for _, spec := range decl.Specs {
+		vspec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		for _, name := range vspec.Names {
+			if s, ok := ti.Defs[name].(*types.Const); ok && types.Identical(s.Type(), c.Type()) {
+				sibs = append(sibs, s)
+			}
+		}
+	}
+	return
+}
+
+// usesIota reports whether any value in the const declaration refers to iota.
+func usesIota(decl *ast.GenDecl) (found bool) {
+	for _, spec := range decl.Specs {
+		vspec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		for _, v := range vspec.Values {
+			ast.Inspect(v, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && id.Name == "iota" {
+					found = true
+				}
+				return !found
+			})
+		}
+	}
+	return
+}
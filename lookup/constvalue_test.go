@@ -0,0 +1,65 @@
+package lookup
+
+import "testing"
+
+func TestConstValue(t *testing.T) {
+	tree := newTestTree(t, map[string]string{"e/e.go": `package e
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+func (c Color) String() string { return "" }
+
+const Mask = -0x2a
+
+const (
+	a = iota * 1.5
+	b
+)
+
+const Name string = "e"
+
+var _ = []interface{}{Green, Mask, b, Name}
+`})
+	defer tree.close()
+	s := NewSession(Options{})
+	tests := []struct {
+		at, value string
+	}{
+		{"Green, ", `Constant Value: 1
+Default Type: int
+Hex: 0x1
+Binary: 0b1
+Constants of type e.Color:
+    Red   = 0
+    Green = 1
+    Blue  = 2
+`},
+		{"Mask, ", `Constant Value: -42
+Default Type: int
+Hex: -0x2a
+Binary: -0b101010
+`},
+		// an iota block of untyped constants
+		{"b, ", `Constant Value: 3/2
+Default Type: float64
+Constants of type untyped float:
+    a = 0
+    b = 3/2
+`},
+		{"Name}", `Constant Value: "e"
+Default Type: string
+`},
+	}
+	for _, test := range tests {
+		def := tree.definition(t, s, "e/e.go", test.at, 0)
+		if def.Value != test.value {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.at, def.Value, test.value)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package lookup
+
+type typePos struct {
+	typ, pos string
+}
+
+type declaration struct {
+	name     string
+	kind     string
+	otype    string // type of the object
+	constant string
+	typePos
+	imprt   string
+	pkg     string // name of the package, with imprt
+	doc     string
+	value   string
+	warning string
+	mthds   []*typePos
+	impls   []*typePos // further definitions, e.g. in assembly
+	links   []*typePos // declarations linked with //go:linkname
+	members []*declaration
+}
+
+// definition returns the exported form of d.
+func (d *declaration) definition() *Definition {
+	return &Definition{
+		Name:     d.name,
+		Kind:     d.kind,
+		Type:     d.otype,
+		Constant: d.constant,
+		Pos:      d.pos,
+		Decl:     d.typ,
+		Import:   d.imprt,
+		Pkg:      d.pkg,
+		Doc:      d.doc,
+		Value:    d.value,
+		Warning:  d.warning,
+		Methods:  related(d.mthds),
+		Impls:    related(d.impls),
+		Links:    related(d.links),
+		Members:  definitions(d.members),
+	}
+}
+
+func definitions(list []*declaration) []*Definition {
+	if len(list) == 0 {
+		return nil
+	}
+	defs := make([]*Definition, len(list))
+	for i, d := range list {
+		defs[i] = d.definition()
+	}
+	return defs
+}
+
+func related(list []*typePos) []Related {
+	if len(list) == 0 {
+		return nil
+	}
+	rel := make([]Related, len(list))
+	for i, tp := range list {
+		rel[i] = Related{tp.typ, tp.pos}
+	}
+	return rel
+}
@@ -1,4 +1,4 @@
-package main
+package lookup
 
 import (
 	"bytes"
@@ -100,6 +100,14 @@ func isUpper(name string) bool {
 	return unicode.IsUpper(ch)
 }
 
+// formatDecl prints the declaration node found without type information,
+// as formatNode does for the type-checked ones.
+func (ti *typeInfo) formatDecl(n ast.Node) string {
+	return formatNode(n, nil, ti.fset, ti.showUnexported())
+}
+
+// formatNode prints the declaration node of obj without its documentation
+// and function body. Without obj, a GenDecl is printed with all its specs.
 func formatNode(n ast.Node, obj types.Object, fset *token.FileSet, showUnexported bool) string {
 	//fmt.Printf("formatting %T node\n", n)
 	var nc ast.Node
@@ -121,7 +129,7 @@ func formatNode(n ast.Node, obj types.Object, fset *token.FileSet, showUnexporte
 		// go/printer doesn't include the import paths in names, while
 		// Object.String does. Fix that.
 
-		return obj.String()
+		return objString(obj)
 	case *ast.TypeSpec:
 		specCp := *n
 		if showUnexported == false {
@@ -140,19 +148,36 @@ func formatNode(n ast.Node, obj types.Object, fset *token.FileSet, showUnexporte
 			// Only print this one type, not all the types in the gendecl
 			switch n.Specs[0].(type) {
 			case *ast.TypeSpec:
-				spec := findTypeSpec(n, obj.Pos())
-				if spec != nil {
-					specCp := *spec
-					if showUnexported == false {
-						trimUnexportedElems(&specCp)
+				var specs []*ast.TypeSpec
+				if obj != nil {
+					if spec := findTypeSpec(n, obj.Pos()); spec != nil {
+						specs = append(specs, spec)
+					}
+				} else {
+					for _, spec := range n.Specs {
+						specs = append(specs, spec.(*ast.TypeSpec))
+					}
+				}
+				if specs != nil {
+					cp.Specs = nil
+					for _, spec := range specs {
+						specCp := *spec
+						if showUnexported == false {
+							trimUnexportedElems(&specCp)
+						}
+						specCp.Doc = nil
+						cp.Specs = append(cp.Specs, &specCp)
 					}
-					specCp.Doc = nil
-					cp.Specs = []ast.Spec{&specCp}
 				}
 				cp.Lparen = 0
 				cp.Rparen = 0
 			case *ast.ValueSpec:
-				spec := findVarSpec(n, obj.Pos())
+				var spec *ast.ValueSpec
+				if obj != nil {
+					spec = findVarSpec(n, obj.Pos())
+				} else if len(n.Specs) == 1 {
+					spec = n.Specs[0].(*ast.ValueSpec)
+				}
 				if spec != nil {
 					specCp := *spec
 					specCp.Doc = nil
@@ -165,18 +190,25 @@ func formatNode(n ast.Node, obj types.Object, fset *token.FileSet, showUnexporte
 		nc = &cp
 
 	default:
-		return obj.String()
+		return objString(obj)
 	}
 
 	buf := &bytes.Buffer{}
 	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
 	err := cfg.Fprint(buf, fset, nc)
 	if err != nil {
-		return obj.String()
+		return objString(obj)
 	}
 	return buf.String()
 }
 
+func objString(obj types.Object) string {
+	if obj == nil {
+		return ""
+	}
+	return obj.String()
+}
+
 func findTypeSpec(decl *ast.GenDecl, pos token.Pos) *ast.TypeSpec {
 	for _, spec := range decl.Specs {
 		typeSpec := spec.(*ast.TypeSpec)
@@ -0,0 +1,114 @@
+package lookup
+
+import (
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// Kinds of the declarations of Definition.Kind.
+const (
+	KindConst  = "const"
+	KindVar    = "var" // including struct fields
+	KindFunc   = "func"
+	KindType   = "type"
+	KindImport = "import" // a package name declared by an import
+	KindLabel  = "label"
+	KindPath   = "path" // the path of an import, declaring the package
+)
+
+// describe sets the kind, the type and the constant value of dcl, the
+// declaration of obj. The type of a type name is its underlying type.
+func (ti *typeInfo) describe(dcl *declaration, obj types.Object) {
+	qualifier := func(pkg *types.Package) string {
+		if pkg == obj.Pkg() {
+			return ""
+		}
+		return pkg.Name()
+	}
+	switch o := obj.(type) {
+	case *types.Const:
+		dcl.kind, dcl.constant = KindConst, o.Val().String()
+	case *types.Var, *types.Nil:
+		dcl.kind = KindVar
+	case *types.Func, *types.Builtin:
+		dcl.kind = KindFunc
+	case *types.TypeName:
+		dcl.kind = KindType
+		dcl.otype = types.TypeString(o.Type().Underlying(), qualifier)
+		return
+	case *types.PkgName:
+		dcl.kind, dcl.constant = KindImport, strconv.Quote(o.Imported().Path())
+		return
+	case *types.Label:
+		dcl.kind = KindLabel
+		return
+	}
+	if typ := obj.Type(); typ != nil && typ != types.Typ[types.Invalid] {
+		dcl.otype = types.TypeString(typ, qualifier)
+	}
+}
+
+// describeNode sets the kind, the type and the constant value of dcl from
+// the syntax of its declaration node, as found by findTopLevelDecl, for
+// declarations without an object.
+func describeNode(dcl *declaration, node ast.Node) {
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		dcl.kind, dcl.otype = KindFunc, types.ExprString(n.Type)
+	case *ast.TypeSpec:
+		dcl.kind, dcl.otype = KindType, types.ExprString(n.Type)
+	case *ast.GenDecl:
+		dcl.kind = KindVar
+		if n.Tok == token.CONST {
+			dcl.kind = KindConst
+		}
+		for _, spec := range n.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			if vs.Type != nil {
+				dcl.otype = types.ExprString(vs.Type)
+			}
+			for i, id := range vs.Names {
+				if id.Name == dcl.name && n.Tok == token.CONST && i < len(vs.Values) {
+					dcl.constant = types.ExprString(vs.Values[i])
+				}
+			}
+		}
+	}
+}
+
+// members returns the fields and the methods of typ, or of the type typ
+// points to, sorted by name.
+func (ti *typeInfo) members(typ types.Type) (members []*declaration) {
+	if p, ok := typ.(*types.Pointer); ok {
+		typ = p.Elem()
+	}
+	add := func(obj types.Object) {
+		dcl := &declaration{name: obj.Name()}
+		dcl.typ = types.ObjectString(obj, nil)
+		if p := ti.fset.Position(obj.Pos()); p.IsValid() {
+			dcl.pos = p.String()
+		}
+		ti.describe(dcl, obj)
+		members = append(members, dcl)
+	}
+	if s, ok := typ.Underlying().(*types.Struct); ok {
+		for i := 0; i < s.NumFields(); i++ {
+			add(s.Field(i))
+		}
+	}
+	mset := types.NewMethodSet(types.NewPointer(typ))
+	if types.IsInterface(typ) {
+		mset = types.NewMethodSet(typ)
+	}
+	for i := 0; i < mset.Len(); i++ {
+		add(mset.At(i).Obj())
+	}
+	sort.SliceStable(members, func(i, j int) bool { return members[i].name < members[j].name })
+	return
+}
@@ -0,0 +1,181 @@
+package lookup
+
+import (
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A linkname is a //go:linkname directive.
+type linkname struct {
+	local  string    // name of the local function or variable
+	target string    // import path and name of the target, e.g. runtime.nanotime; may be empty
+	pos    token.Pos // of the directive
+	end    token.Pos
+}
+
+// fileLinknames returns the //go:linkname directives of file. Comments are
+// only available if the file was parsed with parser.ParseComments.
+func fileLinknames(file *ast.File) (links []linkname) {
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if !strings.HasPrefix(c.Text, "//go:linkname ") {
+				continue
+			}
+			f := strings.Fields(c.Text[len("//go:linkname "):])
+			if len(f) == 0 || len(f) > 2 {
+				continue
+			}
+			l := linkname{local: f[0], pos: c.Pos(), end: c.End()}
+			if len(f) == 2 {
+				l.target = f[1]
+			}
+			links = append(links, l)
+		}
+	}
+	return
+}
+
+// linknameAt returns the //go:linkname directive of file at pos, if any.
+func linknameAt(file *ast.File, pos token.Pos) *linkname {
+	for _, l := range fileLinknames(file) {
+		if l.pos <= pos && pos < l.end {
+			return &l
+		}
+	}
+	return nil
+}
+
+// splitLinkTarget splits a linkname target into the import path and the
+// name, e.g. "internal/poll.runtime_Semacquire" into "internal/poll" and
+// "runtime_Semacquire". The name of a method is qualified by its receiver
+// type, as in "time.(*Timer).Stop".
+func splitLinkTarget(target string) (path, name string) {
+	slash := strings.LastIndex(target, "/")
+	dot := strings.Index(target[slash+1:], ".")
+	if dot < 0 {
+		return "", ""
+	}
+	dot += slash + 1
+	return target[:dot], target[dot+1:]
+}
+
+// linkTarget looks for the declaration of the linkname target, with the
+// import path resolved from dir.
+func (ti *typeInfo) linkTarget(target, dir string) *declaration {
+	path, name := splitLinkTarget(target)
+	if path == "" {
+		return nil
+	}
+	bp, _ := ti.ctxt.Import(path, dir, 0)
+	if bp == nil || bp.Dir == "" {
+		return nil
+	}
+	dcl := ti.packageIdent(bp.Dir, bp.Name, false, name)
+	if dcl != nil && ti.opts.All {
+		dcl.imprt, dcl.pkg = path, bp.Name
+	}
+	return dcl
+}
+
+// linknameDirective describes the target of the directive l in the file
+// fileName, or its local function if it has no target.
+func (ti *typeInfo) linknameDirective(fileName string, astFile *ast.File, l *linkname) (*declaration, error) {
+	if l.target != "" {
+		if dcl := ti.linkTarget(l.target, filepath.Dir(fileName)); dcl != nil {
+			dcl.links = ti.linkAliases(l.target, dcl.pos)
+			return dcl, nil
+		}
+	}
+	if id, _, _ := findTopLevelDecl(astFile, l.local); id != nil {
+		if obj := ti.Defs[id]; obj != nil {
+			return ti.ident(obj)
+		}
+	}
+	return nil, nil
+}
+
+// linkname follows the //go:linkname directive of the package-level
+// function or variable obj declared in file, if any: a body-less
+// declaration is replaced by its target, otherwise the target is listed.
+// Additionally, the functions and variables of the loaded packages that
+// are linked to obj are listed.
+func (ti *typeInfo) linkname(obj types.Object, file *ast.File, node ast.Node, dcl *declaration) *declaration {
+	if obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+		return dcl
+	}
+	self := &typePos{dcl.typ, dcl.pos}
+	dir := filepath.Dir(ti.fset.Position(obj.Pos()).Filename)
+	for _, l := range fileLinknames(file) {
+		if l.local != obj.Name() || l.target == "" {
+			continue
+		}
+		target := ti.linkTarget(l.target, dir)
+		if target == nil {
+			break
+		}
+		if fd, ok := node.(*ast.FuncDecl); ok && fd.Body == nil {
+			target.links = append(target.links, self)
+			target.links = append(target.links, ti.linkAliases(l.target, self.pos, target.pos)...)
+			return target
+		}
+		dcl.links = append(dcl.links, &typePos{target.typ, target.pos})
+		break
+	}
+	dcl.links = append(dcl.links, ti.linkAliases(obj.Pkg().Path()+"."+obj.Name(), self.pos)...)
+	return dcl
+}
+
+// linkAliases lists the functions and variables of the loaded packages
+// that are linked to target, except those at the positions in skip, in
+// the order of their positions.
+func (ti *typeInfo) linkAliases(target string, skip ...string) (aliases []*typePos) {
+	var positions []token.Position
+	for _, pkg := range ti.importer.CachedPackages() {
+		for _, file := range pkg.Files {
+			for _, l := range fileLinknames(file) {
+				if l.target != target {
+					continue
+				}
+				id, node, _ := findTopLevelDecl(file, l.local)
+				if id == nil {
+					continue
+				}
+				p := ti.fset.Position(id.Pos())
+				if pos := p.String(); !contains(skip, pos) {
+					skip = append(skip, pos)
+					aliases = append(aliases, &typePos{ti.formatDecl(node), pos})
+					positions = append(positions, p)
+				}
+			}
+		}
+	}
+	sort.Sort(byPosition{aliases, positions})
+	return
+}
+
+// byPosition sorts declarations by their positions, by file, line and
+// column.
+type byPosition struct {
+	list      []*typePos
+	positions []token.Position
+}
+
+func (a byPosition) Len() int { return len(a.list) }
+func (a byPosition) Less(i, j int) bool {
+	p, q := a.positions[i], a.positions[j]
+	if p.Filename != q.Filename {
+		return p.Filename < q.Filename
+	}
+	if p.Line != q.Line {
+		return p.Line < q.Line
+	}
+	return p.Column < q.Column
+}
+func (a byPosition) Swap(i, j int) {
+	a.list[i], a.list[j] = a.list[j], a.list[i]
+	a.positions[i], a.positions[j] = a.positions[j], a.positions[i]
+}
@@ -0,0 +1,57 @@
+package lookup
+
+import "testing"
+
+func TestLinkname(t *testing.T) {
+	// the aliases of b.f are at lines 6 and 13, sorted by line
+	src := `package a
+
+import _ "unsafe"
+
+//go:linkname f2 b.f
+func f2()
+
+//go:linkname m b.(*T).m
+func m()
+
+//go:linkname v b.T.v
+//go:linkname f b.f
+func f()
+`
+	tree := newTestTree(t, map[string]string{
+		"a/a.go": src,
+		"b/b.go": "package b\n\ntype T struct{}\n\nfunc (t *T) m() {}\n\nfunc (T) v() {}\n\nfunc f() {}\n",
+	})
+	defer tree.close()
+	fileName, bFile := tree.path("a/a.go"), tree.path("b/b.go")
+	s := NewSession(Options{})
+	tests := []struct {
+		at, decl, pos string
+		links         []Related
+	}{
+		{"b.f\nfunc f()", "func f()", bFile + ":9:6", []Related{
+			{"func f2()", fileName + ":6:6"},
+			{"func f()", fileName + ":13:6"},
+		}},
+		{"b.(*T).m", "func (t *T) m()", bFile + ":5:13", []Related{
+			{"func m()", fileName + ":9:6"},
+		}},
+		{"b.T.v", "func (T) v()", bFile + ":7:10", nil},
+	}
+	for _, test := range tests {
+		def := tree.definition(t, s, "a/a.go", test.at, 0)
+		if def.Decl != test.decl || def.Pos != test.pos {
+			t.Errorf("%s: got %q at %s, want %q at %s", test.at, def.Decl, def.Pos, test.decl, test.pos)
+		}
+		if len(def.Links) != len(test.links) {
+			t.Errorf("%s: got links %v, want %v", test.at, def.Links, test.links)
+			continue
+		}
+		for i := range test.links {
+			if def.Links[i] != test.links[i] {
+				t.Errorf("%s: got links %v, want %v", test.at, def.Links, test.links)
+				break
+			}
+		}
+	}
+}
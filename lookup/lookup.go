@@ -0,0 +1,252 @@
+// Package lookup finds the declarations of the identifiers in Go source
+// files, as the gogetdef command does.
+//
+// A Session keeps the packages it parsed and type-checked, so that
+// successive lookups in the same packages are fast:
+//
+//	s := lookup.NewSession(lookup.Options{All: true})
+//	def, err := s.Definition(ctx, "/src/a/a.go", 123, nil)
+//
+// The overlay passed to the lookups replaces the contents of files on
+// disk, e.g. with the unsaved buffers of an editor; a nil content marks a
+// file as deleted. Changes of files on disk are not noticed by a session.
+package lookup
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sync"
+)
+
+// Column units and position formats of Options.
+const (
+	Bytes  = "bytes"  // columns count bytes
+	Runes  = "runes"  // columns count runes
+	UTF16  = "utf16"  // columns count UTF-16 code units, as in LSP
+	Offset = "offset" // positions are byte offsets, as in foo.go:#123
+)
+
+// Options configure a Session.
+type Options struct {
+	// All selects the complete description of declarations: with the
+	// import path, documentation and methods, and with the unexported
+	// fields and methods of types.
+	All bool
+
+	// If HideUnexported is set, the unexported fields and methods are
+	// left out of the declarations of types also with All, as gogetdoc
+	// does by default.
+	HideUnexported bool
+
+	// GOOS, GOARCH and Tags select the build context. Those not set are
+	// chosen so that the file looked up is part of its package.
+	GOOS, GOARCH string
+	Tags         []string
+
+	// ColumnUnit is the unit of the columns of line and column positions
+	// passed to DefinitionAt: Bytes, the default, Runes or UTF16.
+	ColumnUnit string
+
+	// PosFormat is the format of the positions of the results: a line
+	// and a column in Bytes, the default, Runes or UTF16, or an Offset.
+	PosFormat string
+
+	// If Members is set, the fields and methods of the type of a type
+	// name or variable are listed in Definition.Members.
+	Members bool
+
+	// If NormalizeNewlines is set, the CRLF line endings of overlay files
+	// are converted to LF. Offsets into the files are remapped, so they
+	// remain byte offsets into the overlay contents.
+	NormalizeNewlines bool
+}
+
+// A Pos is a position in a file: a byte offset, or a line and column if
+// Offset is negative. Lines and columns start at 1.
+type Pos struct {
+	File      string
+	Offset    int
+	Line, Col int
+}
+
+// A Definition describes the declaration of an identifier.
+type Definition struct {
+	Name     string
+	Kind     string        // one of the Kind constants, or empty if not known
+	Type     string        // type of the object; for a type name, its underlying type
+	Constant string        // value of a constant, or quoted path of an import
+	Pos      string        // position of the declaration
+	Decl     string        // text of the declaration
+	Import   string        // import path of the package, with Options.All
+	Pkg      string        // name of the package, with Options.All
+	Doc      string        // documentation, with Options.All
+	Value    string        // value of constants and descriptions of struct tags
+	Warning  string        // caveats of an approximate or incomplete result
+	Methods  []Related     // with Options.All
+	Impls    []Related     // further definitions, e.g. in assembly
+	Links    []Related     // declarations linked with //go:linkname
+	Members  []*Definition // with Options.Members
+}
+
+// A Related is a declaration related to a Definition, or a struct field
+// listed by TagRefs.
+type Related struct {
+	Decl string
+	Pos  string
+}
+
+// A Session looks up declarations, sharing the loaded packages between
+// lookups. When the overlay changes, only the packages affected by the
+// changed files are loaded again. Its methods may be called
+// concurrently, but lookups are run one at a time; a lookup in a file
+// cancels the one running in the same file, which then returns what it
+// found so far.
+type Session struct {
+	opts Options
+
+	mu      sync.Mutex // guards running
+	running map[string]*run
+
+	lookupMu sync.Mutex // guards the fields below
+	ti       *typeInfo
+	overlay  map[string][]byte
+}
+
+// A run is a lookup in progress.
+type run struct {
+	cancel context.CancelFunc
+}
+
+// NewSession returns a session looking up declarations with opts.
+func NewSession(opts Options) *Session {
+	return &Session{opts: opts, running: make(map[string]*run)}
+}
+
+// begin starts a lookup in fileName, cancelling the one running in it.
+// The lookup holds the session until end is called.
+func (s *Session) begin(ctx context.Context, fileName string) (_ context.Context, end func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &run{cancel}
+	fileName = filepath.Clean(fileName)
+	s.mu.Lock()
+	if prev := s.running[fileName]; prev != nil {
+		prev.cancel()
+	}
+	s.running[fileName] = r
+	s.mu.Unlock()
+
+	s.lookupMu.Lock()
+	return ctx, func() {
+		s.lookupMu.Unlock()
+		s.mu.Lock()
+		if s.running[fileName] == r {
+			delete(s.running, fileName)
+		}
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+// typeInfo returns the type information of the session for overlay,
+// updated with the files that changed since the previous lookup.
+func (s *Session) typeInfo(overlay map[string][]byte) *typeInfo {
+	if s.ti == nil {
+		s.ti = newTypeInfo(&s.opts, overlay)
+	} else if changed := changedFiles(s.overlay, overlay); len(changed) > 0 {
+		s.ti.updateOverlay(overlay, changed)
+	} else {
+		return s.ti
+	}
+	s.overlay = make(map[string][]byte, len(overlay))
+	for filename, content := range overlay {
+		s.overlay[filename] = content
+	}
+	return s.ti
+}
+
+// changedFiles returns the names of the files added, removed or changed
+// in the overlay b compared to a.
+func changedFiles(a, b map[string][]byte) (changed []string) {
+	for filename, content := range a {
+		other, ok := b[filename]
+		if !ok || (content == nil) != (other == nil) || !bytes.Equal(content, other) {
+			changed = append(changed, filename)
+		}
+	}
+	for filename := range b {
+		if _, ok := a[filename]; !ok {
+			changed = append(changed, filename)
+		}
+	}
+	return
+}
+
+// Definition returns the declaration of the identifier at the byte
+// offset in file. When ctx is done, the declaration is looked up in what
+// was loaded so far, and a result is marked with a warning.
+func (s *Session) Definition(ctx context.Context, file string, offset int, overlay map[string][]byte) (*Definition, error) {
+	return s.DefinitionAt(ctx, Pos{File: file, Offset: offset}, overlay)
+}
+
+// DefinitionAt is like Definition, for a position that may be given as a
+// line and column.
+func (s *Session) DefinitionAt(ctx context.Context, pos Pos, overlay map[string][]byte) (*Definition, error) {
+	ctx, end := s.begin(ctx, pos.File)
+	defer end()
+	ti := s.typeInfo(overlay)
+
+	offset, err := ti.offsetOf(pos)
+	if err != nil {
+		return nil, err
+	}
+	dcl, err := ti.findDeclaration(ctx, pos.File, offset)
+	if err != nil {
+		return nil, err
+	}
+	ti.formatPositions(dcl)
+	return dcl.definition(), nil
+}
+
+// Prepare announces the positions of the following lookups. Function
+// bodies are only parsed and checked if they contain a position looked
+// up, and a file is parsed and checked again for a position in a body it
+// was parsed without, so announcing the positions lets all the lookups
+// share the files and packages. Positions that can't be converted to
+// offsets are ignored.
+func (s *Session) Prepare(positions []Pos, overlay map[string][]byte) {
+	s.lookupMu.Lock()
+	defer s.lookupMu.Unlock()
+	ti := s.typeInfo(overlay)
+
+	files := make(map[string]bool)
+	for _, pos := range positions {
+		if offset, err := ti.offsetOf(pos); err == nil {
+			fileName := filepath.Clean(pos.File)
+			ti.addQuery(fileName, offset)
+			files[fileName] = true
+		}
+	}
+	for fileName := range files {
+		ti.parseQueryFile(context.Background(), fileName)
+	}
+}
+
+// TagRefs lists the struct fields of the packages loaded for file whose
+// tag has the given key and whose value is named name, as in the query
+// json:user_id.
+func (s *Session) TagRefs(ctx context.Context, file, query string, overlay map[string][]byte) ([]Related, error) {
+	ctx, end := s.begin(ctx, file)
+	defer end()
+	ti := s.typeInfo(overlay)
+
+	refs, err := ti.findTagRefs(ctx, file, query)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Related, len(refs))
+	for i, ref := range refs {
+		list[i] = Related{ref.typ, ti.formatPos(ref.pos)}
+	}
+	return list, nil
+}
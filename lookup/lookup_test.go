@@ -0,0 +1,297 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A testTree is a temporary GOPATH whose files are in an overlay.
+type testTree struct {
+	dir     string
+	gopath  string // restored by close
+	overlay map[string][]byte
+}
+
+// newTestTree makes a temporary directory the GOPATH of the lookups until
+// close is called, with files, by their slash-separated paths relative to
+// its src directory, in the overlay.
+func newTestTree(t *testing.T, files map[string]string) *testTree {
+	dir, err := ioutil.TempDir("", "lookup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := &testTree{dir: dir, gopath: build.Default.GOPATH, overlay: make(map[string][]byte)}
+	build.Default.GOPATH = dir
+	for name, src := range files {
+		tree.set(name, src)
+	}
+	return tree
+}
+
+func (tree *testTree) close() {
+	build.Default.GOPATH = tree.gopath
+	os.RemoveAll(tree.dir)
+}
+
+// path returns the file name of name.
+func (tree *testTree) path(name string) string {
+	return filepath.Join(tree.dir, "src", filepath.FromSlash(name))
+}
+
+// set sets the contents of name in the overlay.
+func (tree *testTree) set(name, src string) {
+	tree.overlay[tree.path(name)] = []byte(src)
+}
+
+// offset returns the offset of the first occurrence of at in name.
+func (tree *testTree) offset(name, at string) int {
+	return strings.Index(string(tree.overlay[tree.path(name)]), at)
+}
+
+// definition looks up the identifier at the first occurrence of at in
+// name, plus skip bytes.
+func (tree *testTree) definition(t *testing.T, s *Session, name, at string, skip int) *Definition {
+	t.Helper()
+	def, err := s.Definition(context.Background(), tree.path(name), tree.offset(name, at)+skip, tree.overlay)
+	if err != nil {
+		t.Fatalf("looking up %s: %v", at, err)
+	}
+	return def
+}
+
+func TestSessionReuse(t *testing.T) {
+	src := `package a
+
+func f() int {
+	x := 1
+	return x
+}
+
+func g() string {
+	y := "a"
+	return y
+}
+`
+	tree := newTestTree(t, map[string]string{"a/a.go": src})
+	defer tree.close()
+	s := NewSession(Options{})
+
+	// the body of g is skipped for the first lookup
+	for _, want := range []string{"var x int", "var y string"} {
+		name := want[len("var ") : len("var ")+1]
+		def := tree.definition(t, s, "a/a.go", "return "+name, len("return "))
+		if def.Decl != want {
+			t.Errorf("looking up %s: got %q, want %q", name, def.Decl, want)
+		}
+	}
+
+	// a changed overlay is loaded again
+	tree.set("a/a.go", strings.Replace(src, `y := "a"`, `y := 'a'`, 1))
+	if def := tree.definition(t, s, "a/a.go", "return y", len("return ")); def.Decl != "var y rune" {
+		t.Errorf("after changing the overlay: got %q, want %q", def.Decl, "var y rune")
+	}
+}
+
+func TestDefinitionKind(t *testing.T) {
+	tree := newTestTree(t, map[string]string{"a/a.go": `package a
+
+const N = 1 << 2
+
+type T struct {
+	X int
+	y string
+}
+
+func (t *T) M() {}
+
+func f() {
+	var v T
+	v.M()
+	_ = N
+}
+`})
+	defer tree.close()
+	s := NewSession(Options{Members: true})
+
+	tests := []struct {
+		at, ident           string
+		kind, typ, constant string
+		members             []string
+	}{
+		{at: "_ = N", ident: "N", kind: KindConst, typ: "untyped int", constant: "4"},
+		{at: "var v T", ident: "T", kind: KindType, typ: "struct{X int; y string}", members: []string{"M", "X", "y"}},
+		{at: "v.M()", ident: "v", kind: KindVar, typ: "T", members: []string{"M", "X", "y"}},
+	}
+	for _, test := range tests {
+		def := tree.definition(t, s, "a/a.go", test.at, strings.Index(test.at, test.ident))
+		if def.Kind != test.kind || def.Type != test.typ || def.Constant != test.constant {
+			t.Errorf("looking up %s: got %s %q = %q, want %s %q = %q", test.at,
+				def.Kind, def.Type, def.Constant, test.kind, test.typ, test.constant)
+		}
+		var members []string
+		for _, m := range def.Members {
+			members = append(members, m.Name)
+		}
+		if strings.Join(members, " ") != strings.Join(test.members, " ") {
+			t.Errorf("looking up %s: got members %v, want %v", test.at, members, test.members)
+		}
+	}
+}
+
+func TestHideUnexported(t *testing.T) {
+	tree := newTestTree(t, map[string]string{"a/a.go": `package a
+
+type T struct {
+	X int
+	y string
+}
+
+var v T
+`})
+	defer tree.close()
+	for _, hide := range []bool{false, true} {
+		def := tree.definition(t, NewSession(Options{All: true, HideUnexported: hide}), "a/a.go", "v T", len("v "))
+		if def.Pkg != "a" {
+			t.Errorf("HideUnexported=%v: got package %q, want %q", hide, def.Pkg, "a")
+		}
+		if got := strings.Contains(def.Decl, "y string"); got == hide {
+			t.Errorf("HideUnexported=%v: got declaration %q", hide, def.Decl)
+		}
+	}
+}
+
+func TestVariableMethods(t *testing.T) {
+	tree := newTestTree(t, map[string]string{"a/a.go": `package a
+
+type T int
+
+func (T) M() {}
+
+var v T
+`})
+	defer tree.close()
+	def := tree.definition(t, NewSession(Options{All: true}), "a/a.go", "v T", 0)
+	if len(def.Methods) != 1 || def.Methods[0].Decl != "func (T) M()" {
+		t.Errorf("got methods %v, want func (T) M()", def.Methods)
+	}
+}
+
+func TestBrokenDependency(t *testing.T) {
+	tree := newTestTree(t, map[string]string{
+		"a/a.go": "package a\n\nimport \"b\"\n\nvar A = b.F()\n",
+		"b/b.go": "package b\n\nfunc F() int { return 1 }\n\nfunc G( {\n",
+	})
+	defer tree.close()
+	def := tree.definition(t, NewSession(Options{All: true}), "a/a.go", "F()", 0)
+	if def.Decl != "func F() int" {
+		t.Errorf("got %q, want %q", def.Decl, "func F() int")
+	}
+	if !strings.Contains(def.Warning, "Package b has errors") {
+		t.Errorf("got warning %q, want the errors of package b", def.Warning)
+	}
+}
+
+func TestCancelledLookup(t *testing.T) {
+	tree := newTestTree(t, map[string]string{
+		"a/a.go": "package a\n\nimport \"b\"\n\nvar A = b.V\n",
+		"b/b.go": "package b\n\n// T is a type.\ntype T int\n\nvar V T = 1\n",
+	})
+	defer tree.close()
+	s := NewSession(Options{})
+	// parses b, but checks it only as an import of a
+	tree.definition(t, s, "a/a.go", "V", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bFile := tree.path("b/b.go")
+	def, err := s.Definition(ctx, bFile, tree.offset("b/b.go", "T ="), tree.overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Decl != "type T int" || def.Warning != incomplete {
+		t.Errorf("cancelled: got %q with warning %q, want %q with warning %q", def.Decl, def.Warning, "type T int", incomplete)
+	}
+
+	// the partially checked package b is not kept
+	def = tree.definition(t, s, "b/b.go", "T =", 0)
+	if def.Decl != "type T int" || def.Warning != "" || def.Pos != bFile+":4:6" {
+		t.Errorf("after cancelling: got %q at %s with warning %q", def.Decl, def.Pos, def.Warning)
+	}
+}
+
+func TestSessionKeepsDependencies(t *testing.T) {
+	aSrc := "package a\n\nimport \"b\"\n\nvar A = b.F()\n"
+	tree := newTestTree(t, map[string]string{
+		"a/a.go": aSrc,
+		"b/b.go": "package b\n\nfunc F() int { return 1 }\n",
+	})
+	defer tree.close()
+	s := NewSession(Options{})
+	lookupF := func(want string) *types.Package {
+		t.Helper()
+		if def := tree.definition(t, s, "a/a.go", "F()", 0); def.Decl != want {
+			t.Errorf("got %q, want %q", def.Decl, want)
+		}
+		pkg, err := s.ti.importer.ImportFrom("b", filepath.Dir(tree.path("a/a.go")), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+	b := lookupF("func F() int")
+
+	// editing a keeps b
+	tree.set("a/a.go", strings.Replace(aSrc, "var A", "var AA", 1))
+	if lookupF("func F() int") != b {
+		t.Error("package b was loaded again after editing package a")
+	}
+
+	// editing b loads it again
+	tree.set("b/b.go", "package b\n\nfunc F() string { return \"\" }\n")
+	if lookupF("func F() string") == b {
+		t.Error("package b was kept after editing it")
+	}
+}
+
+func TestTooManyErrors(t *testing.T) {
+	for _, n := range []int{10, 11, 15} {
+		src := "package a\n\n"
+		for i := 0; i < n; i++ {
+			src += fmt.Sprintf("var _ = undefined%02d\n", i)
+		}
+		tree := newTestTree(t, map[string]string{"a/a.go": src})
+		_, err := NewSession(Options{All: true}).Definition(context.Background(), tree.path("a/a.go"), tree.offset("a/a.go", "undefined00"), tree.overlay)
+		tree.close()
+		if err == nil {
+			t.Fatalf("%d errors: no error", n)
+		}
+		lines := strings.Split(err.Error(), "\n")
+		want := n
+		if n > 10 {
+			want = 11
+		}
+		if len(lines) != want || (n > 10) != (lines[len(lines)-1] == "...") {
+			t.Errorf("%d errors: got %d lines, ending with %q", n, len(lines), lines[len(lines)-1])
+		}
+	}
+}
+
+func TestExternalTestPackage(t *testing.T) {
+	tree := newTestTree(t, map[string]string{
+		"e/e.go":           "package e\n\nfunc F() int { return 1 }\n",
+		"e/export_test.go": "package e\n\n// Helper is F for the tests.\nfunc Helper() int { return F() }\n",
+		"e/e_test.go":      "package e_test\n\nimport \"e\"\n\nvar _ = e.Helper()\n",
+	})
+	defer tree.close()
+	def := tree.definition(t, NewSession(Options{}), "e/e_test.go", "Helper", 0)
+	if want := tree.path("e/export_test.go") + ":4:6"; def.Decl != "func Helper() int" || def.Pos != want {
+		t.Errorf("got %q at %s, want %q at %s", def.Decl, def.Pos, "func Helper() int", want)
+	}
+}
@@ -0,0 +1,153 @@
+package lookup
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/imports"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// source returns the contents of fileName, as seen through the overlay.
+func (ti *typeInfo) source(fileName string) ([]byte, error) {
+	if src, ok := ti.srcs[fileName]; ok {
+		return src, nil
+	}
+	rc, err := imports.OpenFile(ti.ctxt, fileName)
+	if err != nil {
+		return nil, err
+	}
+	src, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+	ti.srcs[fileName] = src
+	return src, nil
+}
+
+// lineAt returns the byte offset of the given 1-based line of src and its
+// text, without the line ending.
+func lineAt(src []byte, line int) (offset int, text []byte, ok bool) {
+	for n := 1; n < line; n++ {
+		i := bytes.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return 0, nil, false
+		}
+		offset += i + 1
+	}
+	text = src[offset:]
+	if i := bytes.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return offset, bytes.TrimSuffix(text, []byte("\r")), true
+}
+
+// crlfSource returns the original contents of fileName if its newlines
+// were normalised with the NormalizeNewlines option.
+func (ti *typeInfo) crlfSource(fileName string) []byte {
+	if content, ok := ti.crlf[fileName]; ok {
+		return content
+	}
+	for name, content := range ti.crlf {
+		if imports.SameFile(fileName, name) {
+			return content
+		}
+	}
+	return nil
+}
+
+// offsetOf returns the byte offset of pos, whose column is counted in
+// units of the ColumnUnit option. Offsets into files with normalised
+// newlines are remapped.
+func (ti *typeInfo) offsetOf(pos Pos) (int, error) {
+	if pos.Offset >= 0 {
+		if orig := ti.crlfSource(pos.File); orig != nil {
+			return imports.NormalizedOffset(orig, pos.Offset), nil
+		}
+		return pos.Offset, nil
+	}
+	src, err := ti.source(pos.File)
+	if err != nil {
+		return 0, err
+	}
+	offset, text, ok := lineAt(src, pos.Line)
+	if !ok {
+		return 0, fmt.Errorf("%s has no line %d", pos.File, pos.Line)
+	}
+	// a column after the end of the line denotes its end
+	i, n := 0, 1
+	for i < len(text) && n < pos.Col {
+		r, size := utf8.DecodeRune(text[i:])
+		switch ti.opts.ColumnUnit {
+		case Bytes:
+			size = 1
+		case UTF16:
+			n += utf16.RuneLen(r) - 1
+		}
+		n++
+		i += size
+	}
+	return offset + i, nil
+}
+
+// formatPos converts the position p, as printed by token.Position, to the
+// format selected with the PosFormat option. Positions it can't convert
+// are returned unchanged.
+func (ti *typeInfo) formatPos(p string) string {
+	if ti.opts.PosFormat == Bytes || ti.opts.PosFormat == "" {
+		return p
+	}
+	csep := strings.LastIndex(p, ":")
+	if csep <= 0 {
+		return p
+	}
+	lsep := strings.LastIndex(p[:csep], ":")
+	if lsep <= 0 {
+		return p
+	}
+	fileName := p[:lsep]
+	line, err1 := strconv.Atoi(p[lsep+1 : csep])
+	col, err2 := strconv.Atoi(p[csep+1:])
+	if err1 != nil || err2 != nil || col < 1 {
+		return p
+	}
+	src, err := ti.source(fileName)
+	if err != nil {
+		return p
+	}
+	offset, text, ok := lineAt(src, line)
+	if !ok || col-1 > len(text) {
+		return p
+	}
+	prefix := text[:col-1]
+	switch ti.opts.PosFormat {
+	case Runes:
+		col = utf8.RuneCount(prefix) + 1
+	case UTF16:
+		col = 1
+		for _, r := range string(prefix) {
+			col += utf16.RuneLen(r)
+		}
+	case Offset:
+		offset += col - 1
+		if orig := ti.crlfSource(fileName); orig != nil {
+			offset = imports.OriginalOffset(orig, offset)
+		}
+		return fmt.Sprintf("%s:#%d", fileName, offset)
+	}
+	return fmt.Sprintf("%s:%d:%d", fileName, line, col)
+}
+
+// formatPositions converts all the positions of dcl with formatPos.
+func (ti *typeInfo) formatPositions(dcl *declaration) {
+	dcl.pos = ti.formatPos(dcl.pos)
+	for _, list := range [][]*typePos{dcl.mthds, dcl.impls, dcl.links} {
+		for _, tp := range list {
+			tp.pos = ti.formatPos(tp.pos)
+		}
+	}
+}
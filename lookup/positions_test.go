@@ -0,0 +1,89 @@
+package lookup
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// posSrc has a two byte rune at column 10 and a rune outside the BMP, a
+// surrogate pair in UTF-16, at byte column 12; x follows them.
+const posSrc = "package p\n\nvar s = \"é😀x\"\n"
+
+func TestOffsetOf(t *testing.T) {
+	fileName := filepath.Join(getTestDataDir(), "positions", "p.go")
+	overlay := map[string][]byte{fileName: []byte(posSrc)}
+	tests := []struct {
+		unit      string
+		line, col int
+		offset    int
+	}{
+		{Bytes, 3, 10, 20},
+		{Bytes, 3, 12, 22},
+		{Bytes, 3, 16, 26},
+		{Runes, 3, 10, 20},
+		{Runes, 3, 11, 22},
+		{Runes, 3, 12, 26},
+		{UTF16, 3, 10, 20},
+		{UTF16, 3, 11, 22},
+		{UTF16, 3, 13, 26},
+		// past the end of the line
+		{Runes, 3, 99, 28},
+		// at EOF
+		{Runes, 4, 1, len(posSrc)},
+		{UTF16, 4, 1, len(posSrc)},
+	}
+	for _, test := range tests {
+		ti := newTypeInfo(&Options{ColumnUnit: test.unit}, overlay)
+		offset, err := ti.offsetOf(Pos{File: fileName, Offset: -1, Line: test.line, Col: test.col})
+		if err != nil {
+			t.Errorf("%s %d:%d: %v", test.unit, test.line, test.col, err)
+			continue
+		}
+		if offset != test.offset {
+			t.Errorf("%s %d:%d: got offset %d, want %d", test.unit, test.line, test.col, offset, test.offset)
+		}
+	}
+
+	ti := newTypeInfo(&Options{}, overlay)
+	if _, err := ti.offsetOf(Pos{File: fileName, Offset: -1, Line: 5, Col: 1}); err == nil {
+		t.Error("got no error for a line after EOF")
+	}
+	if offset, err := ti.offsetOf(Pos{File: fileName, Offset: len(posSrc)}); err != nil || offset != len(posSrc) {
+		t.Errorf("offset at EOF: got %d, %v", offset, err)
+	}
+}
+
+func TestFormatPos(t *testing.T) {
+	fileName := filepath.Join(getTestDataDir(), "positions", "p.go")
+	overlay := map[string][]byte{fileName: []byte(posSrc)}
+	tests := []struct {
+		format string
+		pos    string
+		want   string
+	}{
+		{Bytes, ":3:16", ":3:16"},
+		{Runes, ":3:10", ":3:10"},
+		{Runes, ":3:12", ":3:11"},
+		{Runes, ":3:16", ":3:12"},
+		{UTF16, ":3:12", ":3:11"},
+		{UTF16, ":3:16", ":3:13"},
+		{Offset, ":3:16", ":#26"},
+		// at EOF
+		{Runes, ":4:1", ":4:1"},
+		{UTF16, ":4:1", ":4:1"},
+		{Offset, ":4:1", ":#29"},
+		// not convertible
+		{Runes, ":3:99", ":3:99"},
+		{Runes, ":5:1", ":5:1"},
+	}
+	for _, test := range tests {
+		ti := newTypeInfo(&Options{PosFormat: test.format}, overlay)
+		if got := ti.formatPos(fileName + test.pos); got != fileName+test.want {
+			t.Errorf("%s %s: got %s, want %s", test.format, test.pos, got, fileName+test.want)
+		}
+	}
+	ti := newTypeInfo(&Options{PosFormat: Runes}, overlay)
+	if got := ti.formatPos(fileName); got != fileName {
+		t.Errorf("got %s for a position without line", got)
+	}
+}
@@ -0,0 +1,180 @@
+package lookup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type tagPair struct {
+	key, value string
+}
+
+// parseStructTag splits a struct tag into its key:"value" pairs, following
+// the conventions of reflect.StructTag.
+func parseStructTag(tag string) (pairs []tagPair, err error) {
+	for tag != "" {
+		// Skip leading space.
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a syntax error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return pairs, fmt.Errorf("bad syntax for struct tag pair: %s", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return pairs, fmt.Errorf("bad syntax for struct tag value: %s", tag)
+		}
+		qvalue := tag[:i+1]
+		tag = tag[i+1:]
+
+		value, err := strconv.Unquote(qvalue)
+		if err != nil {
+			return pairs, fmt.Errorf("bad syntax for struct tag value: %s", qvalue)
+		}
+		pairs = append(pairs, tagPair{key, value})
+	}
+	return
+}
+
+// tagName returns the name part of a tag value, i.e. the value
+// without options like ",omitempty".
+func tagName(value string) string {
+	if i := strings.Index(value, ","); i >= 0 {
+		return value[:i]
+	}
+	return value
+}
+
+// structTag describes the tag literal lit of the struct field field.
+func (ti *typeInfo) structTag(field *ast.Field, lit *ast.BasicLit) (dcl *declaration, err error) {
+	tag, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	pairs, perr := parseStructTag(tag)
+
+	buf := &bytes.Buffer{}
+	for _, p := range pairs {
+		fmt.Fprintf(buf, "%s: %s\n", p.key, p.value)
+	}
+	if perr != nil {
+		fmt.Fprintf(buf, "%s\n", perr)
+	}
+
+	dcl = &declaration{}
+	dcl.typ = "tag " + lit.Value
+	if len(field.Names) > 0 {
+		dcl.name = field.Names[0].Name
+		if obj := ti.Defs[field.Names[0]]; obj != nil {
+			dcl.typ = obj.String() + " " + lit.Value
+		}
+	}
+	dcl.pos = ti.fset.Position(field.Pos()).String()
+	dcl.value = buf.String()
+	return
+}
+
+// findTagRefs lists the struct fields of the packages loaded for fileName
+// whose tag has the given key and whose value is named name.
+func (ti *typeInfo) findTagRefs(ctx context.Context, fileName, query string) (refs []*typePos, err error) {
+	ti.ctx = ctx
+	sep := strings.Index(query, ":")
+	if sep <= 0 {
+		return nil, fmt.Errorf("invalid tag query %s, want key:name", query)
+	}
+	key, name := query[:sep], query[sep+1:]
+
+	astFile, err := ti.importer.ParseFile(ctx, fileName, nil)
+	if err != nil {
+		return
+	}
+	if astFile.Name.Name == "" {
+		return nil, errors.New("can't get package name")
+	}
+	if _, err = ti.checkPackage(fileName, astFile, nil); err != nil {
+		return
+	}
+
+	var fields []*ast.Field
+	seen := make(map[token.Pos]bool)
+	for _, astPkg := range ti.importer.CachedPackages() {
+		for _, file := range astPkg.Files {
+			ast.Inspect(file, func(node ast.Node) bool {
+				field, ok := node.(*ast.Field)
+				if !ok || field.Tag == nil || seen[field.Pos()] {
+					return true
+				}
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					return true
+				}
+				pairs, _ := parseStructTag(tag)
+				for _, p := range pairs {
+					if p.key == key && tagName(p.value) == name {
+						seen[field.Pos()] = true
+						fields = append(fields, field)
+						break
+					}
+				}
+				return true
+			})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		pi, pj := ti.fset.Position(fields[i].Pos()), ti.fset.Position(fields[j].Pos())
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	for _, field := range fields {
+		refs = append(refs, ti.tagRef(field))
+	}
+	return
+}
+
+func (ti *typeInfo) tagRef(field *ast.Field) *typePos {
+	ref := &typePos{pos: ti.fset.Position(field.Pos()).String()}
+	var names []string
+	for _, id := range field.Names {
+		if obj, ok := ti.Defs[id].(*types.Var); ok {
+			names = append(names, obj.String())
+		} else {
+			names = append(names, id.Name)
+		}
+	}
+	if len(names) == 0 {
+		names = append(names, types.ExprString(field.Type))
+	}
+	ref.typ = strings.Join(names, ", ") + " " + field.Tag.Value
+	return ref
+}
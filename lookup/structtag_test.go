@@ -0,0 +1,50 @@
+package lookup
+
+import (
+	"context"
+	"testing"
+)
+
+const structTagSrc = "package a\n\n" +
+	"type User struct {\n" +
+	"\tID    int    `json:\"user_id,omitempty\" db:\"id\"`\n" +
+	"\tName  string `json:\"name\" db:\"user_id\"`\n" +
+	"\tEmail string `json:\"user_idx\"`\n" +
+	"\tBad   string `json:\"bad`\n" +
+	"\tWorse string `json`\n" +
+	"}\n"
+
+func TestStructTag(t *testing.T) {
+	tree := newTestTree(t, map[string]string{"a/a.go": structTagSrc})
+	defer tree.close()
+	s := NewSession(Options{})
+
+	tests := []struct {
+		at, decl, value string
+	}{
+		{"db:\"id\"", "field ID int `json:\"user_id,omitempty\" db:\"id\"`", "json: user_id,omitempty\ndb: id\n"},
+		// malformed tags are described up to the error
+		{"bad`", "field Bad string `json:\"bad`", "bad syntax for struct tag value: \"bad\n"},
+		{"json`", "field Worse string `json`", "bad syntax for struct tag pair: json\n"},
+	}
+	for _, test := range tests {
+		def := tree.definition(t, s, "a/a.go", test.at, 0)
+		if def.Decl != test.decl || def.Value != test.value {
+			t.Errorf("looking up %s: got %q with %q, want %q with %q", test.at, def.Decl, def.Value, test.decl, test.value)
+		}
+	}
+}
+
+func TestTagRefs(t *testing.T) {
+	tree := newTestTree(t, map[string]string{"a/a.go": structTagSrc})
+	defer tree.close()
+	aFile := tree.path("a/a.go")
+	refs, err := NewSession(Options{}).TagRefs(context.Background(), aFile, "json:user_id", tree.overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Related{"field ID int `json:\"user_id,omitempty\" db:\"id\"`", aFile + ":4:2"}
+	if len(refs) != 1 || refs[0] != want {
+		t.Errorf("got %q, want %q", refs, []Related{want})
+	}
+}
@@ -0,0 +1,158 @@
+package lookup
+
+import (
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const approximate = "Approximate: resolved from the syntax only, as the type checker could not resolve the identifier."
+
+// syntacticIdent is the fallback for an identifier the type checker could
+// not resolve. It uses the scopes built by the parser, the top-level
+// declarations of the package and of imported packages, and the universe,
+// so the result may be wrong, e.g. for shadowed names.
+func (ti *typeInfo) syntacticIdent(fileName string, astFile *ast.File, path []ast.Node) *declaration {
+	if len(path) == 0 {
+		return nil
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	var dcl *declaration
+	if len(path) > 2 {
+		// the parser does not resolve the keys of composite literals,
+		// which may be field names
+		if kv, ok := path[1].(*ast.KeyValueExpr); ok && kv.Key == id {
+			if _, ok := path[2].(*ast.CompositeLit); ok {
+				return nil
+			}
+		}
+	}
+	if len(path) > 1 {
+		if sel, ok := path[1].(*ast.SelectorExpr); ok && sel.Sel == id {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+				dcl = ti.qualifiedIdent(fileName, astFile, x.Name, id.Name)
+			}
+			if dcl == nil {
+				return nil
+			}
+		}
+	}
+	if dcl == nil && id.Obj != nil {
+		dcl = ti.objectIdent(id.Obj)
+	}
+	if dcl == nil && id.Obj == nil {
+		dcl = ti.packageIdent(filepath.Dir(fileName), astFile.Name.Name, strings.HasSuffix(fileName, "_test.go"), id.Name)
+	}
+	if dcl == nil && id.Obj == nil {
+		if obj := types.Universe.Lookup(id.Name); obj != nil {
+			dcl, _ = ti.builtinIdent(obj)
+		}
+	}
+	if dcl != nil {
+		dcl.warning = approximate
+	}
+	return dcl
+}
+
+// objectIdent describes the object obj found by the parser.
+func (ti *typeInfo) objectIdent(obj *ast.Object) *declaration {
+	dcl := &declaration{name: obj.Name}
+	dcl.pos = ti.fset.Position(obj.Pos()).String()
+
+	var doc *ast.CommentGroup
+	switch d := obj.Decl.(type) {
+	case *ast.FuncDecl:
+		dcl.typ, doc = ti.formatDecl(d), d.Doc
+		describeNode(dcl, d)
+	case *ast.TypeSpec:
+		dcl.typ, doc = ti.formatDecl(d), d.Doc
+		describeNode(dcl, d)
+	case *ast.ValueSpec:
+		tok := token.VAR
+		if obj.Kind == ast.Con {
+			tok = token.CONST
+		}
+		gen := &ast.GenDecl{Tok: tok, Specs: []ast.Spec{d}}
+		dcl.typ, doc = ti.formatDecl(gen), d.Doc
+		describeNode(dcl, gen)
+	case *ast.Field:
+		dcl.kind, dcl.otype = KindVar, types.ExprString(d.Type)
+		dcl.typ = obj.Kind.String() + " " + obj.Name + " " + types.ExprString(d.Type)
+		doc = d.Doc
+		if doc == nil {
+			doc = d.Comment
+		}
+	default:
+		// short variable declarations, labels, ...
+		dcl.typ = obj.Kind.String() + " " + obj.Name
+		switch obj.Kind {
+		case ast.Var:
+			dcl.kind = KindVar
+		case ast.Lbl:
+			dcl.kind = KindLabel
+		}
+	}
+	if ti.opts.All {
+		dcl.doc = doc.Text()
+	}
+	return dcl
+}
+
+// packageIdent looks for the top-level declaration of name in the files of
+// package pkgName in dir.
+func (ti *typeInfo) packageIdent(dir, pkgName string, withTests bool, name string) *declaration {
+	astFiles, _ := ti.importer.ParseDir(ti.ctx, dir)
+	for _, file := range astFiles {
+		if file == nil || file.Name.Name != pkgName || (!withTests && ti.isTestFile(file)) {
+			continue
+		}
+		if id, node, doc := findTopLevelDecl(file, name); node != nil {
+			dcl := &declaration{name: name}
+			dcl.pos = ti.fset.Position(id.Pos()).String()
+			dcl.typ = ti.formatDecl(node)
+			describeNode(dcl, node)
+			if ti.opts.All {
+				dcl.doc = doc.Text()
+			}
+			return dcl
+		}
+	}
+	return nil
+}
+
+// qualifiedIdent looks for the declaration of the qualified identifier
+// pkgName.name, with pkgName imported in astFile.
+func (ti *typeInfo) qualifiedIdent(fileName string, astFile *ast.File, pkgName, name string) *declaration {
+	dir, _ := filepath.Abs(filepath.Dir(fileName))
+	for _, spec := range astFile.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil && spec.Name.Name != pkgName {
+			continue
+		}
+		bp, err := ti.ctxt.Import(path, dir, 0)
+		if bp == nil || bp.Dir == "" || (spec.Name == nil && bp.Name != pkgName) {
+			continue
+		}
+		if _, ok := err.(*build.NoGoError); ok {
+			continue
+		}
+		if dcl := ti.packageIdent(bp.Dir, bp.Name, false, name); dcl != nil {
+			if ti.opts.All {
+				dcl.imprt, dcl.pkg = path, bp.Name
+			}
+			return dcl
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package lookup
+
+import (
+	"github.com/JohnWall2016/gogetdef/imports"
+	"github.com/JohnWall2016/gogetdef/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const syntacticSrc = `package a
+
+import "b"
+
+type T struct {
+	X int
+	y int
+}
+
+func f() {
+	v := b.F()
+	var t T
+	_ = t.X
+}
+`
+
+// syntacticTree returns a GOPATH whose package b fails to import, as its
+// directory holds two packages, and a package a using it.
+func syntacticTree(t *testing.T) *testTree {
+	return newTestTree(t, map[string]string{
+		"b/b.go": "package b\n\n// F is f.\nfunc F() int { return 1 }\n",
+		"b/c.go": "package c\n",
+		"a/a.go": syntacticSrc,
+	})
+}
+
+func TestQualifiedIdent(t *testing.T) {
+	tree := syntacticTree(t)
+	defer tree.close()
+	def := tree.definition(t, NewSession(Options{All: true}), "a/a.go", "F()", 0)
+	if def.Decl != "func F() int" || def.Import != "b" || def.Doc != "F is f.\n" {
+		t.Errorf("got %q from %q with doc %q", def.Decl, def.Import, def.Doc)
+	}
+	if want := tree.path("b/b.go") + ":4:6"; def.Pos != want {
+		t.Errorf("got position %s, want %s", def.Pos, want)
+	}
+	if def.Warning != approximate {
+		t.Errorf("got warning %q, want %q", def.Warning, approximate)
+	}
+}
+
+func TestSyntacticIdent(t *testing.T) {
+	tree := syntacticTree(t)
+	defer tree.close()
+	fileName := tree.path("a/a.go")
+	ti := newTypeInfo(&Options{}, tree.overlay)
+	astFile, err := parser.ParseFile(ti.fset, fileName, syntacticSrc, parser.ParseComments, func(int, int) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := ti.fset.File(astFile.Pos()).Base()
+
+	tests := []struct {
+		at, decl, pos string
+	}{
+		// the declarations are printed as by the type-checked lookups
+		{"T\n", "type T struct {\n\tX int\n\t// Has unexported fields.\n}", "a.go:5:6"},
+		{"t.X", "var t T", "a.go:12:6"},
+		{"v :=", "var v", "a.go:11:2"},
+	}
+	for _, test := range tests {
+		pos := base + strings.Index(syntacticSrc, test.at)
+		path, _ := imports.PathEnclosingInterval(astFile, token.Pos(pos), token.Pos(pos))
+		dcl := ti.syntacticIdent(fileName, astFile, path)
+		if dcl == nil {
+			t.Errorf("%q: not found", test.at)
+			continue
+		}
+		if dcl.typ != test.decl || !strings.HasSuffix(dcl.pos, test.pos) || dcl.warning != approximate {
+			t.Errorf("%q: got %q at %s (%q), want %q at %s", test.at, dcl.typ, dcl.pos, dcl.warning, test.decl, test.pos)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package asm
+
+// f is implemented in assembly.
+func f() int
+
+func g(x int) int
+
+func use() int {
+	return f() + g(1)
+}
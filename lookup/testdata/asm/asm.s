@@ -0,0 +1,7 @@
+#include "textflag.h"
+
+TEXT ·f(SB),NOSPLIT,$0-8
+	RET
+
+TEXT asm·g(SB),NOSPLIT,$0-16
+	RET
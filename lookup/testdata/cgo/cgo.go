@@ -0,0 +1,13 @@
+package cgo
+
+// #cgo CFLAGS: -I${SRCDIR}/include
+// #include <stdio.h>
+// #include "point.h"
+// #define LIMIT 10
+import "C"
+
+func use() {
+	s := C.CString("hello")
+	C.puts(s)
+	_ = C.norm(C.struct_point{}, C.LIMIT)
+}
@@ -0,0 +1,612 @@
+package lookup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/imports"
+	"github.com/JohnWall2016/gogetdef/parser"
+	"github.com/JohnWall2016/gogetdef/types"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type typeInfo struct {
+	types.Info
+	opts     *Options
+	fset     *token.FileSet
+	importer *imports.Importer
+	ctxt     *build.Context
+	errors   []error
+	maxerrs  int
+	srcs     map[string][]byte       // file contents read to convert positions
+	queries  map[string][]int        // offsets looked up, by file name
+	checked  map[string]*checkResult // by package checked and build context
+	crlf     map[string][]byte       // original contents of overlay files with normalised newlines
+	ctx      context.Context         // of the current lookup
+	overlay  map[string][]byte       // of ctxt
+}
+
+// A checkResult records the type-checking of a package.
+type checkResult struct {
+	cerr   error   // the checker's error
+	errors []error // all the errors reported
+}
+
+// incomplete warns of a result found after the lookup was stopped.
+const incomplete = "Incomplete: the lookup was stopped before the packages were fully checked."
+
+func newTypeInfo(opts *Options, overlay map[string][]byte) *typeInfo {
+	info := &typeInfo{
+		opts: opts,
+		Info: types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Scopes:     make(map[ast.Node]*types.Scope),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		},
+		fset:    token.NewFileSet(),
+		maxerrs: 10,
+		srcs:    make(map[string][]byte),
+		queries: make(map[string][]int),
+		checked: make(map[string]*checkResult),
+		crlf:    make(map[string][]byte),
+		ctx:     context.Background(),
+		overlay: make(map[string][]byte, len(overlay)),
+	}
+	// the overlay is copied, to be updated by updateOverlay
+	for filename, content := range overlay {
+		info.setOverlayFile(filename, content)
+	}
+	info.ctxt = imports.OverlayContext(newContext(opts), info.overlay)
+	// comments are needed for documentation and //go:linkname directives
+	info.importer = imports.NewImporter(info.ctxt, info.fset, &info.Info, parser.ParseComments)
+	info.importer.Tolerant = true
+
+	return info
+}
+
+// setOverlayFile sets the contents of fileName in the overlay, with the
+// newlines normalised if the NormalizeNewlines option is set.
+func (ti *typeInfo) setOverlayFile(fileName string, content []byte) {
+	delete(ti.crlf, fileName)
+	if ti.opts.NormalizeNewlines {
+		if normalized := imports.NormalizeNewlines(content); len(normalized) != len(content) {
+			ti.crlf[fileName] = content
+			content = normalized
+		}
+	}
+	ti.overlay[fileName] = content
+}
+
+// updateOverlay takes the changed files from overlay. The packages in
+// their directories and the packages importing these are loaded again;
+// the other packages are kept.
+func (ti *typeInfo) updateOverlay(overlay map[string][]byte, changed []string) {
+	dirs := make([]string, 0, len(changed))
+	for _, fileName := range changed {
+		if content, ok := overlay[fileName]; ok {
+			ti.setOverlayFile(fileName, content)
+		} else {
+			delete(ti.overlay, fileName)
+			delete(ti.crlf, fileName)
+		}
+		delete(ti.srcs, fileName)
+		delete(ti.queries, filepath.Clean(fileName))
+		dirs = append(dirs, filepath.Dir(filepath.Clean(fileName)))
+	}
+	ti.importer.ForgetDirs(dirs)
+	// the packages looked up in are cheap to check again
+	ti.checked = make(map[string]*checkResult)
+}
+
+func (ti *typeInfo) nodeOfPos(pos token.Pos) (path []ast.Node, node ast.Node) {
+	if file := ti.fset.File(pos); file != nil {
+		path = ti.importer.PathEnclosingInterval(file.Name(), pos, pos)
+		for _, node = range path {
+			switch node.(type) {
+			case *ast.Ident:
+				// continue ascending AST (searching for parent node of the identifier))
+				continue
+			default:
+				return
+			}
+		}
+	}
+	return nil, nil
+}
+
+type funcsByName []*types.Func
+
+func (p funcsByName) Len() int           { return len(p) }
+func (p funcsByName) Less(i, j int) bool { return p[i].Name() < p[j].Name() }
+func (p funcsByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (ti *typeInfo) ident(obj types.Object) (dcl *declaration, err error) {
+	dcl, err = ti.objectDecl(obj)
+	if dcl == nil || err != nil {
+		return
+	}
+	if nodes, node := ti.nodeOfPos(obj.Pos()); len(nodes) > 0 {
+		if file, ok := nodes[len(nodes)-1].(*ast.File); ok {
+			dcl = ti.linkname(obj, file, node, dcl)
+		}
+	}
+	return
+}
+
+func (ti *typeInfo) objectDecl(obj types.Object) (dcl *declaration, err error) {
+	objPos := func(obj types.Object) string {
+		if p := ti.fset.Position(obj.Pos()); p.IsValid() {
+			return p.String()
+		}
+		return ""
+	}
+
+	dcl = &declaration{name: obj.Name()}
+	dcl.typ = obj.String()
+	dcl.pos = objPos(obj)
+	ti.describe(dcl, obj)
+	if ti.opts.Members {
+		switch obj.(type) {
+		case *types.TypeName, *types.Var:
+			dcl.members = ti.members(obj.Type())
+		}
+	}
+
+	nodes, node := ti.nodeOfPos(obj.Pos())
+	if c, ok := obj.(*types.Const); ok {
+		dcl.value = ti.constValue(c, nodes)
+	}
+	if ti.opts.All && obj.Pkg() != nil && !obj.Pkg().Complete() {
+		dcl.warning = ti.importErrors(obj.Pkg())
+	}
+	if node != nil {
+		dcl.typ = formatNode(node, obj, ti.fset, ti.showUnexported())
+		if fd, ok := node.(*ast.FuncDecl); ok && fd.Body == nil {
+			if fn, ok := obj.(*types.Func); ok {
+				dcl.impls = ti.asmImpls(fn)
+			}
+		}
+		if ti.opts.All {
+			var funcs funcsByName
+			if s, ok := obj.Type().(*types.Named); ok {
+				for i := 0; i < s.NumMethods(); i++ {
+					funcs = append(funcs, s.Method(i))
+				}
+			}
+			if _, ok := obj.(*types.TypeName); ok {
+				// including the methods of embedded interfaces
+				if iface, ok := obj.Type().Underlying().(*types.Interface); ok {
+					iface = iface.Complete()
+					for i := 0; i < iface.NumMethods(); i++ {
+						funcs = append(funcs, iface.Method(i))
+					}
+				}
+			}
+			if len(funcs) > 0 {
+				sort.Sort(funcs)
+				for _, m := range funcs {
+					_, mnode := ti.nodeOfPos(m.Pos())
+					if mnode != nil {
+						mtyp := formatNode(mnode, m, ti.fset, ti.showUnexported())
+						mpos := objPos(m)
+						dcl.mthds = append(dcl.mthds, &typePos{mtyp, mpos})
+					}
+				}
+			}
+
+			if nodes != nil {
+				if obj.Pkg() != nil {
+					dcl.imprt, dcl.pkg = obj.Pkg().Path(), obj.Pkg().Name()
+				}
+				for _, node := range nodes {
+					//fmt.Printf("for %s: found %T\n%#v\n", id.Name, node, node)
+					switch n := node.(type) {
+					case *ast.Ident:
+						continue
+					case *ast.FuncDecl:
+						dcl.doc = n.Doc.Text()
+						return
+					case *ast.Field:
+						if n.Doc != nil {
+							dcl.doc = n.Doc.Text()
+						} else if n.Comment != nil {
+							dcl.doc = n.Comment.Text()
+						}
+						return
+					case *ast.TypeSpec:
+						if n.Doc != nil {
+							dcl.doc = n.Doc.Text()
+							return
+						}
+						if n.Comment != nil {
+							dcl.doc = n.Comment.Text()
+							return
+						}
+					case *ast.ValueSpec:
+						if n.Doc != nil {
+							dcl.doc = n.Doc.Text()
+							return
+						}
+						if n.Comment != nil {
+							dcl.doc = n.Comment.Text()
+							return
+						}
+					case *ast.GenDecl:
+						if dcl.doc == "" && n.Doc != nil {
+							dcl.doc = n.Doc.Text()
+						}
+						return
+					default:
+						return
+					}
+				}
+			}
+		}
+	} else if obj.Pkg() == nil || obj.Pkg() == types.Unsafe {
+		return ti.builtinIdent(obj)
+	}
+	return
+}
+
+// importErrors describes the errors of the incomplete package pkg.
+func (ti *typeInfo) importErrors(pkg *types.Package) string {
+	errs := ti.importer.Errors(pkg.Path())
+	if len(errs) == 0 {
+		return ""
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Package %s has errors, its information may be incomplete:\n", pkg.Path())
+	for i, e := range errs {
+		if i == ti.maxerrs {
+			fmt.Fprintln(buf, "    ...")
+			break
+		}
+		fmt.Fprintf(buf, "    %s\n", e)
+	}
+	return buf.String()
+}
+
+func (ti *typeInfo) importSpec(spec *ast.ImportSpec) (dcl *declaration, err error) {
+	path, _ := strconv.Unquote(spec.Path.Value)
+	bpkg, err := build.Import(path, "", build.ImportComment)
+	if err != nil {
+		return
+	}
+	dcl = &declaration{name: bpkg.Name, kind: KindPath, constant: spec.Path.Value}
+	dcl.typ = "package " + bpkg.Name
+	dcl.pos = bpkg.Dir
+	if ti.opts.All {
+		astPkg, ok := ti.importer.GetCachedPackage(bpkg.Name)
+		if ok {
+			docPkg := doc.New(astPkg, path, 0)
+			dcl.doc = docPkg.Doc
+			dcl.imprt, dcl.pkg = path, bpkg.Name
+		}
+	}
+	return
+}
+
+// addQuery registers offset in fileName to be looked up, so that the
+// function bodies containing it are parsed and checked. Adding the
+// queries before the first lookup saves parsing and checking the files
+// again for the function bodies of later queries.
+func (ti *typeInfo) addQuery(fileName string, offset int) {
+	fileName = filepath.Clean(fileName)
+	if !ti.inQuery(fileName, offset, offset) {
+		ti.queries[fileName] = append(ti.queries[fileName], offset)
+	}
+}
+
+// parseQueryFile parses fileName with the function bodies containing its
+// queries. If it was parsed before without one of these, it is parsed and
+// its package is checked again.
+func (ti *typeInfo) parseQueryFile(ctx context.Context, fileName string) (*ast.File, error) {
+	if file, ok := ti.importer.CachedFile(fileName); ok && ti.missesBody(file, ti.queries[fileName]) {
+		ti.importer.ForgetFile(fileName)
+		prefix := filepath.Dir(fileName) + " "
+		for key := range ti.checked {
+			if strings.HasPrefix(key, prefix) {
+				delete(ti.checked, key)
+			}
+		}
+	}
+	return ti.importer.ParseFile(ctx, fileName, func(lbrace, rbrace int) bool {
+		return ti.inQuery(fileName, lbrace, rbrace)
+	})
+}
+
+// missesBody reports whether one of the offsets in file is in a function
+// body that was skipped by the parser, which leaves it empty.
+func (ti *typeInfo) missesBody(file *ast.File, offsets []int) bool {
+	tokFile := ti.fset.File(file.Pos())
+	if tokFile == nil {
+		return false
+	}
+	for _, offset := range offsets {
+		if offset > tokFile.Size() {
+			continue
+		}
+		pos := tokFile.Pos(offset)
+		path, _ := imports.PathEnclosingInterval(file, pos, pos)
+		for i, node := range path {
+			body, ok := node.(*ast.BlockStmt)
+			if !ok || len(body.List) > 0 || i+1 == len(path) || pos <= body.Lbrace || pos >= body.Rbrace {
+				continue
+			}
+			switch path[i+1].(type) {
+			case *ast.FuncDecl, *ast.FuncLit:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inQuery reports whether the body between the offsets lbrace and rbrace
+// of fileName contains a query.
+func (ti *typeInfo) inQuery(fileName string, lbrace, rbrace int) bool {
+	for _, offset := range ti.queries[fileName] {
+		if lbrace <= offset && offset <= rbrace {
+			return true
+		}
+	}
+	return false
+}
+
+// inQueryBody is like inQuery for the body between lbrace and rbrace.
+func (ti *typeInfo) inQueryBody(lbrace, rbrace token.Pos) bool {
+	tokFile := ti.fset.File(lbrace)
+	if tokFile == nil {
+		return false
+	}
+	return ti.inQuery(tokFile.Name(), tokFile.Offset(lbrace), tokFile.Offset(rbrace))
+}
+
+// findDeclaration looks for the declaration of the identifier at offset
+// in fileName. When ctx is done, the packages are left partially checked
+// and the declaration is looked up in what was checked so far.
+func (ti *typeInfo) findDeclaration(ctx context.Context, fileName string, offset int) (dcl *declaration, err error) {
+	ti.ctx = ctx
+	defer func() {
+		if ctx.Err() == nil {
+			return
+		}
+		if dcl != nil && dcl.warning == "" {
+			dcl.warning = incomplete
+		} else if dcl == nil {
+			err = fmt.Errorf("lookup stopped before the declaration was found (%v)", ctx.Err())
+		}
+	}()
+
+	// errors are reported for the packages of this lookup only
+	ti.errors = nil
+	fileName = filepath.Clean(fileName)
+	ti.addQuery(fileName, offset)
+	astFile, err := ti.parseQueryFile(ctx, fileName)
+	var perr error
+	if err != nil {
+		if astFile == nil || !ti.importer.Tolerant {
+			return
+		}
+		// look up in the parts of the file that could be parsed
+		perr, err = err, nil
+		ti.addErrors(perr)
+	}
+
+	if astFile.Name.Name == "" {
+		err = errors.New("can't get package name")
+		return
+	}
+
+	tokFile := ti.fset.File(astFile.Pos())
+	if tokFile == nil {
+		return nil, errors.New("can't get token file")
+	}
+	if offset > tokFile.Size() {
+		return nil, errors.New("illegal file offset")
+	}
+	pos := tokFile.Pos(offset)
+
+	cerr, err := ti.checkPackage(fileName, astFile, ti.inQueryBody)
+	if err != nil {
+		return
+	}
+	if cerr == nil {
+		cerr = perr
+	}
+
+	if l := linknameAt(astFile, pos); l != nil {
+		if dcl, err := ti.linknameDirective(fileName, astFile, l); dcl != nil || err != nil {
+			return dcl, err
+		}
+	}
+
+	path, _ := imports.PathEnclosingInterval(astFile, pos, pos)
+
+	for i, node := range path {
+		switch n := node.(type) {
+		case *ast.Ident:
+			if ti.isCgoRef(n, path[i:]) {
+				return ti.cgoIdent(fileName, astFile, n.Name)
+			}
+			var obj types.Object
+			if obj = ti.ObjectOf(n); obj == nil {
+				continue
+			}
+			if v, ok := obj.(*types.Var); ok {
+				if v.IsField() && v.Anonymous() { // embeded type field
+					if i+1 < len(path) {
+						p := path[i+1]
+						i += 1
+						if _, ok := p.(*ast.SelectorExpr); ok { // with a selector
+							if i+1 < len(path) {
+								p = path[i+1]
+								i += 1
+							}
+						}
+						if _, ok := p.(*ast.StarExpr); ok { // pointer type
+							if i+1 < len(path) {
+								p = path[i+1]
+							}
+						}
+						// if in a struct's declaration, find the embeded field's own type
+						if _, ok := p.(*ast.Field); ok {
+							switch tn := v.Type().(type) {
+							case *types.Named:
+								obj = tn.Obj()
+							case *types.Pointer:
+								if tn, ok := tn.Elem().(*types.Named); ok {
+									obj = tn.Obj()
+								}
+							}
+						}
+					}
+				}
+			}
+			return ti.ident(obj)
+		case *ast.ImportSpec:
+			return ti.importSpec(n)
+		case *ast.BasicLit:
+			if i+1 < len(path) {
+				if field, ok := path[i+1].(*ast.Field); ok && field.Tag == n {
+					return ti.structTag(field, n)
+				}
+			}
+		default:
+			break
+		}
+	}
+	if dcl := ti.syntacticIdent(fileName, astFile, path); dcl != nil {
+		return dcl, nil
+	}
+	if cerr != nil && ti.opts.All {
+		errmsg := []string{}
+		for _, e := range ti.errors {
+			errmsg = append(errmsg, e.Error())
+		}
+		sort.Strings(errmsg)
+		if len(errmsg) > ti.maxerrs {
+			errmsg = append(errmsg[:ti.maxerrs], "...")
+		}
+		cerr = errors.New(strings.Join(errmsg, "\n"))
+	}
+	if cerr == nil {
+		//cerr = errors.New(fmt.Sprintf("can't found the node: %#v", node))
+		cerr = errors.New("can't find the declaration")
+	}
+	return nil, cerr
+}
+
+// checkPackage type-checks astFile together with the files of the same
+// package in its directory. The returned cerr is the checker's error,
+// err reports a failure to load the package files.
+func (ti *typeInfo) checkPackage(fileName string, astFile *ast.File, checkFuncBodies func(lbrace, rbrace token.Pos) bool) (cerr, err error) {
+	pkgName := astFile.Name.Name
+	// As with go test, test files are only checked along with a test file.
+	isTest := strings.HasSuffix(fileName, "_test.go")
+
+	// packages are checked once per build context, for all queries
+	ctxt := ti.selectContext(fileName)
+	ti.importer.SetContext(ctxt)
+	key := fmt.Sprintf("%s %s %v %s", filepath.Dir(fileName), pkgName, isTest, imports.ContextKey(ctxt))
+	if c, ok := ti.checked[key]; ok {
+		ti.addErrors(c.errors...)
+		return c.cerr, nil
+	}
+	nerrs := len(ti.errors)
+	defer func() {
+		// a package left partially checked is checked again by a later lookup
+		if err == nil && ti.ctx.Err() == nil {
+			ti.checked[key] = &checkResult{cerr, append([]error(nil), ti.errors[nerrs:]...)}
+		}
+	}()
+
+	astFiles, err := ti.importer.ParseDir(ti.ctx, filepath.Dir(fileName))
+	if err != nil {
+		if !ti.importer.Tolerant || astFiles == nil {
+			return
+		}
+		// check the files that could be parsed
+		ti.addErrors(err)
+		cerr, err = err, nil
+	}
+
+	chkFiles := []*ast.File{astFile}
+	for _, afile := range astFiles {
+		if afile != nil && afile.Name.Name == pkgName && afile != astFile && (isTest || !ti.isTestFile(afile)) {
+			chkFiles = append(chkFiles, afile)
+		}
+	}
+
+	if isTest && strings.HasSuffix(pkgName, "_test") {
+		// An external test package sees the package under test
+		// together with its in-package test files.
+		dir, _ := filepath.Abs(filepath.Dir(fileName))
+		ti.importer.IncludeTests = func(path string) bool {
+			bp, err := ctxt.Import(path, dir, build.FindOnly)
+			return err == nil && imports.SameFile(bp.Dir, dir)
+		}
+	} else {
+		ti.importer.IncludeTests = nil
+	}
+
+	conf := &types.Config{
+		Importer:        ti.importer.WithContext(ti.ctx),
+		Context:         ti.ctx,
+		CheckFuncBodies: checkFuncBodies,
+		FakeImportC:     true,
+		Sizes:           types.SizesFor(ctxt.Compiler, ctxt.GOARCH),
+		Error: func(err error) {
+			ti.addErrors(err)
+		},
+	}
+	// the import path is shown as the package of the declarations, as the
+	// go command names an external test package after the one under test
+	path := pkgName
+	if bp, err := ctxt.ImportDir(filepath.Dir(fileName), build.FindOnly); err == nil && bp.ImportPath != "." {
+		path = bp.ImportPath
+		if isTest && strings.HasSuffix(pkgName, "_test") {
+			path += "_test"
+		}
+	}
+	tpkg := types.NewPackage(path, "")
+	if cherr := types.NewChecker(conf, ti.fset, tpkg, &ti.Info, types.NoCheckUsage).Files(chkFiles); cerr == nil {
+		cerr = cherr
+	}
+	return
+}
+
+// addErrors records errors of the lookup, keeping one more than maxerrs
+// to tell that some were left out.
+func (ti *typeInfo) addErrors(errs ...error) {
+	for _, err := range errs {
+		if len(ti.errors) > ti.maxerrs {
+			return
+		}
+		ti.errors = append(ti.errors, err)
+	}
+}
+
+// showUnexported reports whether the unexported fields and methods are
+// shown in the declarations of types.
+func (ti *typeInfo) showUnexported() bool {
+	return ti.opts.All && !ti.opts.HideUnexported
+}
+
+func (ti *typeInfo) isTestFile(file *ast.File) bool {
+	if tokFile := ti.fset.File(file.Pos()); tokFile != nil {
+		return strings.HasSuffix(tokFile.Name(), "_test.go")
+	}
+	return false
+}
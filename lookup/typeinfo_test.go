@@ -0,0 +1,116 @@
+package lookup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/imports"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func getSrcCodeDir() string {
+	if _, file, _, ok := runtime.Caller(0); ok {
+		return filepath.Dir(file)
+	} else {
+		panic("can't get source code dir")
+	}
+}
+
+func getTestDataDir() string {
+	return filepath.Join(getSrcCodeDir(), "testdata")
+}
+
+// checkDef checks the result of a lookup. A position ending with a colon
+// is only the file, whose line numbers vary with the Go release, as does
+// the spelling of the empty interface.
+func checkDef(t *testing.T, offset int, def *Definition, err error, decl, pos string) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("offset %d: %v", offset, err)
+		return
+	}
+	if got := strings.Replace(def.Decl, "interface{}", "any", -1); got != decl {
+		t.Errorf("offset %d: got decl %q, want %q", offset, got, decl)
+	}
+	if strings.HasSuffix(pos, ":") && !strings.HasPrefix(def.Pos, pos) || !strings.HasSuffix(pos, ":") && def.Pos != pos {
+		t.Errorf("offset %d: got pos %s, want %s", offset, def.Pos, pos)
+	}
+}
+
+func goroot(elem ...string) string {
+	return filepath.Join(append([]string{runtime.GOROOT(), "src"}, elem...)...)
+}
+
+func TestBuiltinType(t *testing.T) {
+	testFile := filepath.Join(getTestDataDir(), "file3.go")
+	def, err := NewSession(Options{}).Definition(context.Background(), testFile, 77, nil)
+	checkDef(t, 77, def, err, "func append(slice []Type, elems ...Type) []Type", goroot("builtin", "builtin.go")+":")
+}
+
+func TestNewBuiltins(t *testing.T) {
+	tree := newTestTree(t, map[string]string{"a/a.go": `package a
+
+import "unsafe"
+
+var p unsafe.Pointer
+
+var _, _ = min(1, 2), unsafe.Add(p, 1)
+`})
+	defer tree.close()
+	s := NewSession(Options{})
+
+	tests := []struct {
+		at, decl, file string
+	}{
+		{"min(", "func min[T cmp.Ordered](x T, y ...T) T", goroot("builtin", "builtin.go")},
+		{"Add(", "func Add(ptr Pointer, len IntegerType) Pointer", goroot("unsafe", "unsafe.go")},
+	}
+	for _, test := range tests {
+		def := tree.definition(t, s, "a/a.go", test.at, 0)
+		if def.Decl != test.decl || !strings.HasPrefix(def.Pos, test.file+":") {
+			t.Errorf("looking up %s: got %q at %s, want %q in %s", test.at, def.Decl, def.Pos, test.decl, test.file)
+		}
+	}
+}
+
+func TestFindDeclare(t *testing.T) {
+	testFile := filepath.Join(getTestDataDir(), "file2.go")
+	tests := []struct {
+		offset    int
+		decl, pos string
+	}{
+		{169, "func Title(s string) string", goroot("strings", "strings.go") + ":"},
+		{177, "var aaaa string", testFile + ":9:12"},
+		{146, "func Sprintf(format string, a ...any) string", goroot("fmt", "print.go") + ":"},
+		{142, "package fmt", testFile + ":4:2"},
+		{29, "package fmt", goroot("fmt")},
+		{203, "var dddd string", testFile + ":9:6"},
+	}
+	s := NewSession(Options{})
+	for _, test := range tests {
+		def, err := s.Definition(context.Background(), testFile, test.offset, nil)
+		checkDef(t, test.offset, def, err, test.decl, test.pos)
+	}
+}
+
+func TestOverlayArchive(t *testing.T) {
+	var buf bytes.Buffer
+	testFile := filepath.Join(getTestDataDir(), "file2.go")
+	s := testFile + "\n"
+	buf.Write([]byte(s))
+	buf2, _ := ioutil.ReadFile(testFile)
+	s = fmt.Sprintf("%d\n", len(buf2))
+	buf.Write([]byte(s))
+	buf.Write(buf2)
+
+	overlay, err := imports.ParseOverlayArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, err := NewSession(Options{}).Definition(context.Background(), testFile, 169, overlay)
+	checkDef(t, 169, def, err, "func Title(s string) string", goroot("strings", "strings.go")+":")
+}
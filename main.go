@@ -1,26 +1,43 @@
 package main
 
 import (
-	"errors"
+	"context"
 	"flag"
 	"fmt"
+	"github.com/JohnWall2016/gogetdef/lookup"
+	"go/doc"
 	"io"
 	"os"
-	"strconv"
 	"strings"
 )
 
 var (
-	pos      = flag.String("pos", "", "filename and byte offset of item to find, e.g. foo.go:#123")
-	modified = flag.Bool("modified", false, "read an archive of modified files from standard input")
-	showall  = flag.Bool("all", false, "show all the information of the item")
+	pos         = flag.String("pos", "", "filename and byte offset or line and column of item to find, e.g. foo.go:#123 or foo.go:12:5")
+	colunit     = flag.String("unit", lookup.Bytes, "unit of the column in a -pos with line and column: bytes, runes or utf16")
+	posfmt      = flag.String("posfmt", lookup.Bytes, "format of the printed positions: line and column in bytes, runes or utf16, or offset for foo.go:#123")
+	modified    = flag.Bool("modified", false, "read an archive of modified files from standard input")
+	lf          = flag.Bool("lf", false, "convert the CRLF line endings of modified files to LF, remapping -pos offsets into them")
+	overlayFile = flag.String("overlay", "", "read file replacements from a JSON file in the format of go build -overlay")
+	showall     = flag.Bool("all", false, "show all the information of the item")
+	goos        = flag.String("goos", "", "target operating system, chosen to include the file if not set")
+	goarch      = flag.String("goarch", "", "target architecture, chosen to include the file if not set")
+	buildtags   = flag.String("tags", "", "comma or space separated list of build tags, chosen to include the file if not set")
+	tagrefs     = flag.String("tag-refs", "", "list the struct fields tagged with a key and name, e.g. json:user_id")
+	timeout     = flag.Duration("timeout", 0, "stop a lookup after this long, e.g. 2s, printing what was found so far; 0 means no limit")
+	batch       = flag.Bool("batch", false, "read positions from standard input, one per line, and print a JSON result per line")
+	jsonOut     = flag.Bool("json", false, "print the result as JSON: the location in godef mode, else the document of gogetdoc")
 )
 
 const modifiedUsage = `
 The archive format for the -modified flag consists of the file name, followed
 by a newline, the decimal file size, another newline, and the contents of the file.
+A file size of -1 marks a file as deleted, without contents. The size is in
+bytes and the contents are used exactly as given, unless -lf is set.
 
 This allows editors to supply gogetdef with the contents of their unsaved buffers.
+
+With -batch, the positions are read from standard input up to an empty line,
+which is followed by the archive if -modified is set too.
 `
 
 func main() {
@@ -31,9 +48,17 @@ func main() {
 	}
 	flag.Parse()
 
-	filename, offset, err := parsePos(*pos)
-	if err != nil {
-		fmt.Print(err)
+	if godefMode() {
+		runGodef()
+		return
+	}
+
+	if !contains([]string{lookup.Bytes, lookup.Runes, lookup.UTF16}, *colunit) {
+		fmt.Printf("invalid option: -unit=%s", *colunit)
+		os.Exit(1)
+	}
+	if !contains([]string{lookup.Bytes, lookup.Runes, lookup.UTF16, lookup.Offset}, *posfmt) {
+		fmt.Printf("invalid option: -posfmt=%s", *posfmt)
 		os.Exit(1)
 	}
 
@@ -42,35 +67,153 @@ func main() {
 		archive = os.Stdin
 	}
 
-	dcl, err := findDeclaration(filename, int(offset), archive)
+	if *batch {
+		if err := runBatch(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprint(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *tagrefs != "" {
+		printTagRefs(archive)
+		return
+	}
+
+	p, err := parsePos(*pos)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	overlay, err := readOverlay(archive)
 	if err != nil {
 		fmt.Fprint(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	if *showall {
-		fmt.Print(dcl)
+	ctx, cancel := lookupContext()
+	def, err := newSession().DefinitionAt(ctx, p, overlay)
+	cancel()
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if gogetdocMode() {
+		if err := printGogetdoc(os.Stdout, def); err != nil {
+			fmt.Fprint(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else if *showall {
+		printAll(os.Stdout, def)
 	} else {
 		fmt.Println("gogetdef-return")
-		fmt.Println(dcl.pos)
-		fmt.Print(dcl.typ)
+		fmt.Println(def.Pos)
+		fmt.Print(def.Decl)
+		if def.Value != "" {
+			fmt.Printf("\n%s", def.Value)
+		}
+		if def.Warning != "" {
+			fmt.Printf("\n%s", def.Warning)
+		}
+		if len(def.Impls) > 0 {
+			fmt.Println()
+			fprintRelated(os.Stdout, "impl", def.Impls)
+		}
+		if len(def.Links) > 0 {
+			fmt.Println()
+			fprintRelated(os.Stdout, "linkname", def.Links)
+		}
 	}
 }
 
-func parsePos(p string) (filename string, offset int64, err error) {
-	// foo.go:#123
-	if p == "" {
-		err = errors.New("missing required -pos flag")
-		return
+// newSession returns a lookup session configured by the flags.
+func newSession() *lookup.Session {
+	return lookup.NewSession(lookup.Options{
+		All:               *showall || gogetdocMode(),
+		HideUnexported:    gogetdocMode() && !*unexported,
+		GOOS:              *goos,
+		GOARCH:            *goarch,
+		Tags:              splitTags(*buildtags),
+		ColumnUnit:        *colunit,
+		PosFormat:         *posfmt,
+		NormalizeNewlines: *lf,
+	})
+}
+
+// lookupContext returns the context of a lookup, limited by -timeout.
+func lookupContext() (context.Context, context.CancelFunc) {
+	if *timeout > 0 {
+		return context.WithTimeout(context.Background(), *timeout)
 	}
-	sep := strings.LastIndex(p, ":")
-	// need at least 2 characters after the ':'
-	// (the # sign and the offset)
-	if sep == -1 || sep > len(p)-2 || p[sep+1] != '#' {
-		err = fmt.Errorf("invalid option: -pos=%s", p)
-		return
+	return context.WithCancel(context.Background())
+}
+
+// printAll prints everything known about def, for -all.
+func printAll(w io.Writer, def *lookup.Definition) {
+	if def.Import != "" {
+		fmt.Fprintf(w, "import \"%s\"\n\n", def.Import)
+	}
+	fmt.Fprintf(w, "%s\n\n", def.Decl)
+	if def.Doc != "" {
+		doc.ToText(w, def.Doc, "", "    ", *linelength)
+		fmt.Fprintln(w)
+	}
+	if def.Value != "" {
+		fmt.Fprintf(w, "%s\n", def.Value)
+	}
+	if def.Warning != "" {
+		fmt.Fprintf(w, "%s\n", def.Warning)
+	}
+	fprintRelated(w, "method", def.Methods)
+	fprintRelated(w, "impl", def.Impls)
+	fprintRelated(w, "linkname", def.Links)
+}
+
+// fprintRelated prints a list of related items in the form
+// [:kind:[decl|pos][decl|pos]...], if there are any.
+func fprintRelated(w io.Writer, kind string, list []lookup.Related) {
+	if len(list) > 0 {
+		fmt.Fprintf(w, "[:%s:", kind)
+		for _, r := range list {
+			fmt.Fprintf(w, "[%s|%s]", r.Decl, r.Pos)
+		}
+		fmt.Fprintf(w, "]")
+	}
+}
+
+// printTagRefs prints the fields found for the -tag-refs query, one per
+// line as the position and the field separated by a tab. The -pos flag
+// only selects the package to search from, so its offset is optional.
+func printTagRefs(archive io.Reader) {
+	filename := *pos
+	if p, err := parsePos(*pos); err == nil {
+		filename = p.File
+	}
+	if filename == "" {
+		fmt.Print("missing required -pos flag")
+		os.Exit(1)
+	}
+	if strings.Index(*tagrefs, ":") <= 0 {
+		fmt.Printf("invalid option: -tag-refs=%s", *tagrefs)
+		os.Exit(1)
+	}
+	overlay, err := readOverlay(archive)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := lookupContext()
+	refs, err := newSession().TagRefs(ctx, filename, *tagrefs, overlay)
+	cancel()
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Println("gogetdef-return")
+	for _, ref := range refs {
+		fmt.Printf("%s\t%s\n", ref.Pos, ref.Decl)
 	}
-	filename = p[:sep]
-	offset, err = strconv.ParseInt(p[sep+2:], 10, 32)
-	return
 }
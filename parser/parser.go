@@ -30,6 +30,7 @@ type InFuncBodies func(lbrace, rbrace int) bool
 
 type state struct {
 	nextToken
+	prevEnd   token.Pos
 	scanState scanner.State
 }
 
@@ -62,8 +63,12 @@ type parser struct {
 	// (used to limit the number of calls to syncXXX functions
 	// w/o making scanning progress - avoids potential endless
 	// loops across multiple parser functions during error recovery)
-	syncPos token.Pos // last synchronization position
-	syncCnt int       // number of calls to syncXXX without progress
+	syncPos  token.Pos // last synchronization position
+	syncCnt  int       // number of calls to syncXXX without progress
+	declErrs int       // number of errors before the current top-level declaration
+	prevEnd  token.Pos // end of the last token that is not a comment or an automatic ';'
+	stopPos  token.Pos // last position recovery stopped at, see atDeclBoundary
+	stopCnt  int       // number of times recovery stopped at stopPos
 
 	// Non-syntactic parser control
 	exprLev int  // < 0: in control clause, >= 0: in expression
@@ -267,18 +272,27 @@ func (p *parser) next0() {
 		}
 	}
 
+	if p.pos.IsValid() && p.tok != token.COMMENT && p.tok != token.EOF && !(p.tok == token.SEMICOLON && p.lit == "\n") {
+		n := len(p.lit)
+		if n == 0 {
+			n = len(p.tok.String())
+		}
+		p.prevEnd = p.pos + token.Pos(n)
+	}
 	p.pos, p.tok, p.lit = p.scanner.Scan()
 }
 
 func (p *parser) save() state {
 	return state{
 		nextToken: p.nextToken,
+		prevEnd:   p.prevEnd,
 		scanState: p.scanner.Save(),
 	}
 }
 
 func (p *parser) restore(state state) {
 	p.nextToken = state.nextToken
+	p.prevEnd = state.prevEnd
 	p.scanner.Restore(state.scanState)
 }
 
@@ -380,14 +394,14 @@ func (p *parser) error(pos token.Pos, msg string) {
 	epos := p.file.Position(pos)
 
 	// If AllErrors is not set, discard errors reported on the same line
-	// as the last recorded error and stop parsing if there are more than
-	// 10 errors.
+	// as the last recorded error and stop parsing the current top-level
+	// declaration if there are more than 10 errors in it.
 	if p.mode&AllErrors == 0 {
 		n := len(p.errors)
 		if n > 0 && p.errors[n-1].Pos.Line == epos.Line {
 			return // discard - likely a spurious error
 		}
-		if n > 10 {
+		if n-p.declErrs > 10 {
 			panic(bailout{})
 		}
 	}
@@ -416,11 +430,53 @@ func (p *parser) expect(tok token.Token) token.Pos {
 	pos := p.pos
 	if p.tok != tok {
 		p.errorExpected(pos, "'"+tok.String()+"'")
+		if p.atDeclBoundary() {
+			// leave the next declaration alone, and let the node
+			// closed by tok end with the last token parsed
+			return p.lastEnd() - 1
+		}
 	}
 	p.next() // make progress
 	return pos
 }
 
+// lastEnd returns the end of the last token parsed before the current
+// one, the end of the nodes cut short by a missing token.
+func (p *parser) lastEnd() token.Pos {
+	if p.prevEnd.IsValid() && p.prevEnd < p.pos {
+		return p.prevEnd
+	}
+	return p.pos
+}
+
+// atDeclStart reports whether the current token likely starts a
+// top-level declaration: a declaration keyword at the beginning of a
+// line.
+func (p *parser) atDeclStart() bool {
+	switch p.tok {
+	case token.CONST, token.FUNC, token.IMPORT, token.TYPE, token.VAR:
+		return p.file.Position(p.pos).Column == 1
+	}
+	return false
+}
+
+// atDeclBoundary reports whether the parser, recovering from a syntax
+// error in the current top-level declaration, should stop at the current
+// token as it likely starts the next one. Gofmt never puts statements or
+// nested specs at the beginning of a line, but valid code may, so the
+// heuristic is not applied to declarations without errors.
+func (p *parser) atDeclBoundary() bool {
+	if p.errors.Len() <= p.declErrs || !p.atDeclStart() {
+		return false
+	}
+	// as with syncStmt, give up stopping without progress eventually
+	if p.pos != p.stopPos {
+		p.stopPos, p.stopCnt = p.pos, 0
+	}
+	p.stopCnt++
+	return p.stopCnt <= 100
+}
+
 // expectClosing is like expect but provides a better error message
 // for the common case of a missing comma before a newline.
 //
@@ -444,7 +500,9 @@ func (p *parser) expectSemi() {
 			p.next()
 		default:
 			p.errorExpected(p.pos, "';'")
-			syncStmt(p)
+			if !p.atDeclBoundary() {
+				syncStmt(p)
+			}
 		}
 	}
 }
@@ -504,6 +562,9 @@ func syncStmt(p *parser) {
 		case token.EOF:
 			return
 		}
+		if p.atDeclBoundary() {
+			return
+		}
 		p.next()
 	}
 }
@@ -514,7 +575,11 @@ func syncStmt(p *parser) {
 func syncDecl(p *parser) {
 	for {
 		switch p.tok {
-		case token.CONST, token.TYPE, token.VAR:
+		case token.CONST, token.FUNC, token.IMPORT, token.TYPE, token.VAR:
+			if tok := p.tok; (tok == token.FUNC || tok == token.IMPORT) && !p.atDeclStart() {
+				// likely a function literal or a misplaced import
+				break
+			}
 			// see comments in syncStmt
 			if p.pos == p.syncPos && p.syncCnt < 10 {
 				p.syncCnt++
@@ -648,6 +713,10 @@ func (p *parser) parseType() ast.Expr {
 	if typ == nil {
 		pos := p.pos
 		p.errorExpected(pos, "type")
+		if p.atDeclBoundary() {
+			end := p.lastEnd()
+			return &ast.BadExpr{From: end, To: end}
+		}
 		p.next() // make progress
 		return &ast.BadExpr{From: pos, To: p.pos}
 	}
@@ -827,6 +896,10 @@ func (p *parser) parseVarType(isParam bool) ast.Expr {
 	if typ == nil {
 		pos := p.pos
 		p.errorExpected(pos, "type")
+		if p.atDeclBoundary() {
+			end := p.lastEnd()
+			return &ast.BadExpr{From: end, To: end}
+		}
 		p.next() // make progress
 		typ = &ast.BadExpr{From: pos, To: p.pos}
 	}
@@ -986,7 +1059,15 @@ func (p *parser) parseInterfaceType() *ast.InterfaceType {
 	lbrace := p.expect(token.LBRACE)
 	scope := ast.NewScope(nil) // interface scope
 	var list []*ast.Field
-	for p.tok == token.IDENT {
+	for p.tok == token.IDENT || p.tok == token.INTERFACE {
+		if p.tok == token.INTERFACE {
+			// embedded interface literal
+			doc := p.leadComment
+			typ := p.parseInterfaceType()
+			p.expectSemi() // call before accessing p.linecomment
+			list = append(list, &ast.Field{Doc: doc, Type: typ, Comment: p.lineComment})
+			continue
+		}
 		list = append(list, p.parseMethodSpec(scope))
 	}
 	rbrace := p.expect(token.RBRACE)
@@ -1088,7 +1169,7 @@ func (p *parser) parseStmtList() (list []ast.Stmt) {
 		defer un(trace(p, "StatementList"))
 	}
 
-	for p.tok != token.CASE && p.tok != token.DEFAULT && p.tok != token.RBRACE && p.tok != token.EOF {
+	for p.tok != token.CASE && p.tok != token.DEFAULT && p.tok != token.RBRACE && p.tok != token.EOF && !p.atDeclBoundary() {
 		list = append(list, p.parseStmt())
 	}
 
@@ -1120,6 +1201,17 @@ func (p *parser) parseBody(scope *ast.Scope) *ast.BlockStmt {
 		}
 		p.next()
 	}
+	if !rbrace.IsValid() {
+		// The body is not closed. End it before the first declaration
+		// keyword at the beginning of a line, likely the start of the
+		// next declaration.
+		p.restore(st)
+		for p.tok != token.EOF && !p.atDeclStart() {
+			p.next()
+		}
+		p.errorExpected(p.pos, "'}'")
+		rbrace = p.lastEnd() - 1
+	}
 	if p.parseFuncBodies != nil {
 		lb := p.scanner.Offset(lbrace)
 		rb := p.scanner.Offset(rbrace)
@@ -1304,13 +1396,13 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	p.exprLev++
 	var list []ast.Expr
 	var ellipsis token.Pos
-	for p.tok != token.RPAREN && p.tok != token.EOF && !ellipsis.IsValid() {
+	for p.tok != token.RPAREN && p.tok != token.EOF && !ellipsis.IsValid() && !p.atDeclBoundary() {
 		list = append(list, p.parseRhsOrType()) // builtins may expect a type: make(some type, ...)
 		if p.tok == token.ELLIPSIS {
 			ellipsis = p.pos
 			p.next()
 		}
-		if !p.atComma("argument list", token.RPAREN) {
+		if !p.atComma("argument list", token.RPAREN) || p.atDeclBoundary() {
 			break
 		}
 		p.next()
@@ -1383,7 +1475,7 @@ func (p *parser) parseElementList() (list []ast.Expr) {
 		defer un(trace(p, "ElementList"))
 	}
 
-	for p.tok != token.RBRACE && p.tok != token.EOF {
+	for p.tok != token.RBRACE && p.tok != token.EOF && !p.atDeclBoundary() {
 		list = append(list, p.parseElement())
 		if !p.atComma("composite literal", token.RBRACE) {
 			break
@@ -2400,7 +2492,7 @@ func (p *parser) parseGenDecl(keyword token.Token, f parseSpecFunction) *ast.Gen
 	if p.tok == token.LPAREN {
 		lparen = p.pos
 		p.next()
-		for iota := 0; p.tok != token.RPAREN && p.tok != token.EOF; iota++ {
+		for iota := 0; p.tok != token.RPAREN && p.tok != token.EOF && !p.atDeclBoundary(); iota++ {
 			list = append(list, f(p.leadComment, keyword, iota))
 		}
 		rparen = p.expect(token.RPAREN)
@@ -2495,6 +2587,34 @@ func (p *parser) parseDecl(sync func(*parser)) ast.Decl {
 	return p.parseGenDecl(p.tok, f)
 }
 
+// parseTopLevelDecl parses a top-level declaration. If there are too many
+// errors in it, the declaration is replaced by an *ast.BadDecl and parsing
+// resumes at the next declaration, so that one broken declaration does not
+// cost the rest of the file.
+func (p *parser) parseTopLevelDecl() (decl ast.Decl) {
+	pos := p.pos
+	p.declErrs = p.errors.Len()
+	defer func() {
+		if e := recover(); e != nil {
+			if _, ok := e.(bailout); !ok {
+				panic(e)
+			}
+			// restore the parser state of the file level
+			p.topScope = p.pkgScope
+			p.labelScope = nil
+			p.targetStack = nil
+			p.exprLev = 0
+			p.inRhs = false
+			if p.pos == pos {
+				p.next() // make progress
+			}
+			syncDecl(p)
+			decl = &ast.BadDecl{From: pos, To: p.lastEnd()}
+		}
+	}()
+	return p.parseDecl(syncDecl)
+}
+
 // ----------------------------------------------------------------------------
 // Source files
 
@@ -2538,7 +2658,7 @@ func (p *parser) parseFile() *ast.File {
 		if p.mode&ImportsOnly == 0 {
 			// rest of package body
 			for p.tok != token.EOF {
-				decls = append(decls, p.parseDecl(syncDecl))
+				decls = append(decls, p.parseTopLevelDecl())
 			}
 		}
 	}
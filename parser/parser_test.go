@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func parseAll(lbrace, rbrace int) bool { return true }
+
+// checkDecls checks that the top-level declarations named in want are
+// declared in f, and that the declarations do not overlap.
+func checkDecls(t *testing.T, name string, f *ast.File, want ...string) {
+	for _, n := range want {
+		if f.Scope.Lookup(n) == nil {
+			t.Errorf("%s: %s is not declared", name, n)
+		}
+	}
+	for i := 1; i < len(f.Decls); i++ {
+		if end, next := f.Decls[i-1].End(), f.Decls[i].Pos(); end >= next {
+			t.Errorf("%s: declaration %d ends at %d, after the next one starts at %d", name, i-1, end, next)
+		}
+	}
+}
+
+func TestUnindentedBody(t *testing.T) {
+	const src = "package p\n\nfunc f() int {\nvar x = 1\ntype T int\nreturn x\n}\n"
+	for _, bodies := range []InFuncBodies{nil, parseAll} {
+		f, err := ParseFile(token.NewFileSet(), "p.go", src, 0, bodies)
+		if err != nil {
+			t.Fatalf("parsing bodies %v: %v", bodies != nil, err)
+		}
+		if len(f.Decls) != 1 {
+			t.Fatalf("parsing bodies %v: got %d declarations, want 1", bodies != nil, len(f.Decls))
+		}
+		if body := f.Decls[0].(*ast.FuncDecl).Body; bodies != nil && len(body.List) != 3 {
+			t.Errorf("got %d statements, want 3", len(body.List))
+		}
+	}
+}
+
+func TestRecoverAtDeclBoundary(t *testing.T) {
+	tests := []struct {
+		name, src string
+		decls     []string
+	}{
+		{
+			"unfinished composite literal",
+			"package p\n\nvar x = [\n\ntype Good struct{ X int }\n\nfunc g() {}\n",
+			[]string{"Good", "g"},
+		},
+		{
+			"unclosed call",
+			"package p\n\nimport \"fmt\"\n\nfunc f() {\n\tfmt.Println(\n}\n\ntype Good struct{}\n\nfunc g() {}\n",
+			[]string{"f", "Good", "g"},
+		},
+		{
+			"missing brace",
+			"package p\n\nfunc f() {\n\tif true {\n}\n\nfunc g() {}\n\ntype Good int\n",
+			[]string{"f", "g", "Good"},
+		},
+		{
+			"unclosed struct",
+			"package p\n\ntype Bad struct {\n\tX int\n\nfunc g() {}\n\nvar Good = 1\n",
+			[]string{"g", "Good"},
+		},
+		{
+			"too many errors",
+			"package p\n\nfunc f() {\n" + strings.Repeat("\tx := ) ( ]\n", 12) + "}\n\nfunc g() {}\n",
+			[]string{"g"},
+		},
+	}
+	for _, test := range tests {
+		for _, bodies := range []InFuncBodies{nil, parseAll} {
+			f, err := ParseFile(token.NewFileSet(), "p.go", test.src, 0, bodies)
+			if err == nil && bodies != nil {
+				t.Errorf("%s: no syntax error", test.name)
+			}
+			if f == nil {
+				t.Fatalf("%s: no file", test.name)
+			}
+			checkDecls(t, test.name, f, test.decls...)
+		}
+	}
+}
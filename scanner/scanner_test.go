@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		src string
+		tok token.Token
+		err string
+	}{
+		{"0b1010", token.INT, ""},
+		{"0B_1", token.INT, ""},
+		{"0o755", token.INT, ""},
+		{"0755", token.INT, ""},
+		{"1_000_000", token.INT, ""},
+		{"0x_dead_beef", token.INT, ""},
+		{".5e1_0", token.FLOAT, ""},
+		{"0x1p-2", token.FLOAT, ""},
+		{"0x1.8P+1", token.FLOAT, ""},
+		{"0o17i", token.IMAG, ""},
+		{"0b102", token.INT, "invalid digit '2' in binary literal"},
+		{"0x1.8", token.FLOAT, "hexadecimal mantissa requires a 'p' exponent"},
+		{"1__0", token.INT, "'_' must separate successive digits"},
+		{"0b", token.INT, "binary literal has no digits"},
+	}
+	for _, test := range tests {
+		var s Scanner
+		var errmsg string
+		fset := token.NewFileSet()
+		file := fset.AddFile("", fset.Base(), len(test.src))
+		s.Init(file, []byte(test.src), func(_ token.Position, msg string) {
+			if errmsg == "" {
+				errmsg = msg
+			}
+		}, 0)
+		_, tok, lit := s.Scan()
+		if tok != test.tok || lit != test.src {
+			t.Errorf("%s: got %s %q, want %s", test.src, tok, lit, test.tok)
+		}
+		if errmsg != test.err {
+			t.Errorf("%s: got error %q, want %q", test.src, errmsg, test.err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package p
+
+import "fmt"
+
+// T is a type.
+type T struct {
+	X int
+	y string
+}
+
+// M is a method.
+func (t *T) M() {}
+
+// C is a constant.
+const C = 3
+
+func f() {
+	var v T
+	v.M()
+	fmt.Println(v, C)
+}
@@ -28,6 +28,7 @@ package types
 
 import (
 	"bytes"
+	gocontext "context"
 	"fmt"
 	"go/ast"
 	"go/constant"
@@ -131,6 +132,12 @@ type Config struct {
 	// If Sizes != nil, it provides the sizing functions for package unsafe.
 	// Otherwise SizesFor("gc", "amd64") is used instead.
 	Sizes Sizes
+
+	// If Context != nil, type-checking stops when it is done: the
+	// remaining package-level objects and function bodies are not
+	// checked, the package is not marked complete, and the context's
+	// error is returned. The information recorded so far is kept.
+	Context gocontext.Context
 }
 
 // Info holds result type information for a type-checked package.
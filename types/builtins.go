@@ -182,6 +182,20 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, typ))
 		}
 
+	case _Clear:
+		// clear(m)
+		// clear(s)
+		switch t := x.typ.Underlying().(type) {
+		case *Map, *Slice:
+			x.mode = novalue
+			if check.Types != nil {
+				check.recordBuiltinType(call.Fun, makeSig(nil, t))
+			}
+		default:
+			check.invalidArg(x.pos(), "%s is not a map or slice", x)
+			return
+		}
+
 	case _Close:
 		// close(c)
 		c, _ := x.typ.Underlying().(*Chan)
@@ -454,6 +468,75 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, params[:1+len(sizes)]...))
 		}
 
+	case _Max, _Min:
+		// max(x, ...)
+		// min(x, ...)
+		op := token.LSS
+		if id == _Max {
+			op = token.GTR
+		}
+
+		var params []Type
+		for i := 0; i < nargs; i++ {
+			var a operand
+			if i == 0 {
+				a = *x
+			} else {
+				arg(&a, i)
+				if a.mode == invalid {
+					return
+				}
+			}
+			if !isOrdered(a.typ) {
+				check.invalidArg(a.pos(), "%s cannot be ordered", &a)
+				return
+			}
+
+			// -- first argument: x
+			if i == 0 {
+				params = append(params, a.typ)
+				continue
+			}
+
+			// -- subsequent arguments: convert untyped operands to the other's type
+			switch {
+			case isUntyped(x.typ) && isTyped(a.typ):
+				check.convertUntyped(x, a.typ)
+			case isTyped(x.typ) && isUntyped(a.typ):
+				check.convertUntyped(&a, x.typ)
+			case isUntyped(x.typ) && isUntyped(a.typ) && isNumeric(x.typ) && isNumeric(a.typ):
+				// the larger untyped numeric kind wins
+				if a.typ.(*Basic).kind > x.typ.(*Basic).kind {
+					x.typ = a.typ
+				} else {
+					a.typ = x.typ
+				}
+			}
+			if x.mode == invalid || a.mode == invalid {
+				return
+			}
+			if !Identical(x.typ, a.typ) {
+				check.invalidArg(a.pos(), "mismatched types %s (previous argument) and %s (type of %s)", x.typ, a.typ, a.expr)
+				return
+			}
+			params = append(params, a.typ)
+
+			if x.mode == constant_ && a.mode == constant_ {
+				if constant.Compare(a.val, op, x.val) {
+					x.val = a.val
+				}
+			} else {
+				x.mode = value
+			}
+		}
+
+		if x.mode == constant_ && isFloat(x.typ) {
+			x.val = constant.ToFloat(x.val)
+		}
+		if check.Types != nil && x.mode != constant_ {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, params...))
+		}
+
 	case _New:
 		// new(T)
 		// (no argument evaluated yet)
@@ -512,6 +595,25 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			check.recordBuiltinType(call.Fun, makeSig(x.typ))
 		}
 
+	case _Add:
+		// unsafe.Add(ptr Pointer, len IntegerType) Pointer
+		check.assignment(x, Typ[UnsafePointer], "argument to unsafe.Add")
+		if x.mode == invalid {
+			return
+		}
+
+		var y operand
+		arg(&y, 1)
+		if !check.isValidLength(&y) {
+			return
+		}
+
+		x.mode = value
+		x.typ = Typ[UnsafePointer]
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, x.typ, y.typ))
+		}
+
 	case _Alignof:
 		// unsafe.Alignof(x T) uintptr
 		check.assignment(x, nil, "argument to unsafe.Alignof")
@@ -581,6 +683,72 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		x.typ = Typ[Uintptr]
 		// result is constant - no need to record signature
 
+	case _Slice:
+		// unsafe.Slice(ptr *T, len IntegerType) []T
+		ptr, _ := x.typ.Underlying().(*Pointer)
+		if ptr == nil {
+			check.invalidArg(x.pos(), "%s is not a pointer", x)
+			return
+		}
+
+		var y operand
+		arg(&y, 1)
+		if !check.isValidLength(&y) {
+			return
+		}
+
+		x.mode = value
+		x.typ = NewSlice(ptr.base)
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, ptr, y.typ))
+		}
+
+	case _SliceData:
+		// unsafe.SliceData(slice []T) *T
+		slice, _ := x.typ.Underlying().(*Slice)
+		if slice == nil {
+			check.invalidArg(x.pos(), "%s is not a slice", x)
+			return
+		}
+
+		x.mode = value
+		x.typ = NewPointer(slice.elem)
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, slice))
+		}
+
+	case _String:
+		// unsafe.String(ptr *byte, len IntegerType) string
+		check.assignment(x, NewPointer(universeByte), "argument to unsafe.String")
+		if x.mode == invalid {
+			return
+		}
+
+		var y operand
+		arg(&y, 1)
+		if !check.isValidLength(&y) {
+			return
+		}
+
+		x.mode = value
+		x.typ = Typ[String]
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, NewPointer(universeByte), y.typ))
+		}
+
+	case _StringData:
+		// unsafe.StringData(str string) *byte
+		check.assignment(x, Typ[String], "argument to unsafe.StringData")
+		if x.mode == invalid {
+			return
+		}
+
+		x.mode = value
+		x.typ = NewPointer(universeByte)
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, Typ[String]))
+		}
+
 	case _Assert:
 		// assert(pred) causes a typechecker error if pred is false.
 		// The result of assert is the value of pred if there is no error.
@@ -626,6 +794,30 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 	return true
 }
 
+// isValidLength reports whether x is a valid length argument for the
+// unsafe functions: a value of integer type, or an untyped constant
+// representable by int. Negative constants are rejected.
+func (check *Checker) isValidLength(x *operand) bool {
+	if x.mode == invalid {
+		return false
+	}
+	if isUntyped(x.typ) {
+		check.convertUntyped(x, Typ[Int])
+		if x.mode == invalid {
+			return false
+		}
+	}
+	if !isInteger(x.typ) {
+		check.invalidArg(x.pos(), "length %s must be integer", x)
+		return false
+	}
+	if x.mode == constant_ && constant.Sign(x.val) < 0 {
+		check.invalidArg(x.pos(), "length %s must not be negative", x)
+		return false
+	}
+	return true
+}
+
 // makeSig makes a signature for the given argument and result types.
 // Default types are used for untyped arguments, and res may be nil.
 func makeSig(res Type, args ...Type) *Signature {
@@ -0,0 +1,42 @@
+package types
+
+import "testing"
+
+func TestNewBuiltins(t *testing.T) {
+	runCheckTests(t, []checkTest{
+		// any is interface{}, comparable only resolves
+		{src: "var a any = 1; var _ *interface{} = (*any)(nil); var _ interface{} = a"},
+		{src: "var _ comparable"},
+
+		{src: "var x, y int; var _ int = min(x, y, 1); var _ int = max(x)"},
+		{src: "const c = min(1, 2.5, 'a'); var _ float64 = c"},
+		{src: "var _ string = max(\"a\", \"b\")"},
+		{src: "var x int; var y float64; var _ = min(x, y)", errs: []string{"mismatched types int (previous argument) and float64"}},
+		{src: "var x int; var _ = max(x, 1.5)", errs: []string{"truncated"}},
+		{src: "var b bool; var _ = min(b)", errs: []string{"cannot be ordered"}},
+		{src: "var _ = min()", errs: []string{"not enough arguments"}},
+
+		{src: "func f(m map[string]int, s []int) { clear(m); clear(s) }"},
+		{src: "func f(a [2]int) { clear(a) }", errs: []string{"is not a map or slice"}},
+		{src: "func f(c chan int) { clear(c) }", errs: []string{"is not a map or slice"}},
+		{src: "func f(m map[string]int) { _ = clear(m) }", errs: []string{"used as value"}},
+	})
+}
+
+func TestNewUnsafeFunctions(t *testing.T) {
+	runCheckTests(t, []checkTest{
+		{src: "import \"unsafe\"\n\nvar p unsafe.Pointer; var _ unsafe.Pointer = unsafe.Add(p, 1)"},
+		{src: "import \"unsafe\"\n\nvar p *int; var _ []int = unsafe.Slice(p, uint8(4))"},
+		{src: "import \"unsafe\"\n\nvar b *byte; var _ string = unsafe.String(b, 3)"},
+		{src: "import \"unsafe\"\n\nvar _ *byte = unsafe.StringData(\"a\")"},
+		{src: "import \"unsafe\"\n\nvar s []int; var _ *int = unsafe.SliceData(s)"},
+
+		{src: "import \"unsafe\"\n\nvar p *int; var _ = unsafe.Add(p, 1)", errs: []string{"argument to unsafe.Add"}},
+		{src: "import \"unsafe\"\n\nvar p unsafe.Pointer; var _ = unsafe.Add(p, 1.5)", errs: []string{"truncated"}},
+		{src: "import \"unsafe\"\n\nvar s []int; var _ = unsafe.Slice(s, 1)", errs: []string{"is not a pointer"}},
+		{src: "import \"unsafe\"\n\nvar p *int; var _ = unsafe.Slice(p, -1)", errs: []string{"must not be negative"}},
+		{src: "import \"unsafe\"\n\nvar p *int; var _ = unsafe.String(p, 1)", errs: []string{"argument to unsafe.String"}},
+		{src: "import \"unsafe\"\n\nvar _ = unsafe.StringData(1)", errs: []string{"cannot convert 1"}},
+		{src: "import \"unsafe\"\n\nvar a [2]int; var _ = unsafe.SliceData(a)", errs: []string{"is not a slice"}},
+	})
+}
@@ -86,6 +86,7 @@ type Checker struct {
 	unusedDotImports map[*Scope]map[*Package]token.Pos // positions of unused dot-imported packages for each file scope
 
 	firstErr error                 // first error encountered
+	ctxErr   error                 // error of the done Config.Context
 	methods  map[string][]*Func    // maps type names to associated methods
 	untyped  map[ast.Expr]exprInfo // map of expressions without final type
 	funcs    []funcInfo            // list of functions to type-check
@@ -188,6 +189,7 @@ func (check *Checker) initFiles(files []*ast.File) {
 	check.unusedDotImports = nil
 
 	check.firstErr = nil
+	check.ctxErr = nil
 	check.methods = nil
 	check.untyped = nil
 	check.funcs = nil
@@ -223,12 +225,27 @@ func (check *Checker) handleBailout(err *error) {
 	case nil, bailout:
 		// normal return or early exit
 		*err = check.firstErr
+		if check.ctxErr != nil {
+			*err = check.ctxErr
+		}
 	default:
 		// re-panic
 		panic(p)
 	}
 }
 
+// done reports whether the Config.Context is done, recording its error.
+func (check *Checker) done() bool {
+	if check.ctxErr != nil {
+		return true
+	}
+	if ctx := check.conf.Context; ctx != nil && ctx.Err() != nil {
+		check.ctxErr = ctx.Err()
+		return true
+	}
+	return false
+}
+
 // Files checks the provided files as part of the checker's package.
 func (check *Checker) Files(files []*ast.File) error { return check.checkFiles(files) }
 
@@ -243,6 +260,12 @@ func (check *Checker) checkFiles(files []*ast.File) (err error) {
 
 	check.functionBodies()
 
+	if check.done() {
+		// keep the types of the expressions checked so far
+		check.recordUntyped()
+		return
+	}
+
 	check.initOrder()
 
 	// perform delayed checks
@@ -287,14 +310,14 @@ func (check *Checker) recordTypeAndValue(x ast.Expr, mode operandMode, typ Type,
 }
 
 func (check *Checker) recordBuiltinType(f ast.Expr, sig *Signature) {
-	// f must be a (possibly parenthesized) identifier denoting a built-in
-	// (built-ins in package unsafe always produce a constant result and
-	// we don't record their signatures, so we don't see qualified idents
-	// here): record the signature for f and possible children.
+	// f must be a (possibly parenthesized, possibly qualified)
+	// identifier denoting a built-in (including unsafe's non-constant
+	// functions Add, Slice, etc.): record the signature for f and possible
+	// children.
 	for {
 		check.recordTypeAndValue(f, builtin, sig, nil)
 		switch p := f.(type) {
-		case *ast.Ident:
+		case *ast.Ident, *ast.SelectorExpr:
 			return // we're done
 		case *ast.ParenExpr:
 			f = p.X
@@ -0,0 +1,59 @@
+package types
+
+import (
+	"fmt"
+	"github.com/JohnWall2016/gogetdef/parser"
+	"go/ast"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+type importerFunc func(path string) (*Package, error)
+
+func (f importerFunc) Import(path string) (*Package, error) { return f(path) }
+
+// A checkTest is a package p with the source src, which type-checks with
+// errors containing errs, in order.
+type checkTest struct {
+	src  string
+	errs []string
+}
+
+// checkErrors returns the messages of the errors of checking the package p
+// with the source src, which can import only unsafe.
+func checkErrors(t *testing.T, src string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", "package p\n\n"+src, 0, func(int, int) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []string
+	conf := Config{
+		CheckFuncBodies: func(lbrace, rbrace token.Pos) bool { return true },
+		Importer: importerFunc(func(path string) (*Package, error) {
+			if path == "unsafe" {
+				return Unsafe, nil
+			}
+			return nil, fmt.Errorf("cannot import %s", path)
+		}),
+		Error: func(err error) { errs = append(errs, err.(Error).Msg) },
+	}
+	conf.Check("p", fset, []*ast.File{f}, nil, 0)
+	return errs
+}
+
+func runCheckTests(t *testing.T, tests []checkTest) {
+	t.Helper()
+	for _, test := range tests {
+		errs := checkErrors(t, test.src)
+		ok := len(errs) == len(test.errs)
+		for i := 0; ok && i < len(errs); i++ {
+			ok = strings.Contains(errs[i], test.errs[i])
+		}
+		if !ok {
+			t.Errorf("%s\ngot errors %q, want %q", test.src, errs, test.errs)
+		}
+	}
+}
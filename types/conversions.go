@@ -120,6 +120,22 @@ func (x *operand) convertibleTo(conf *Config, T Type) bool {
 		return true
 	}
 
+	// "x is a slice, T is an array (Go 1.20) or a pointer to an array
+	// (Go 1.17) type, and the slice and array types have identical
+	// element types"
+	if s, ok := Vu.(*Slice); ok {
+		switch t := Tu.(type) {
+		case *Array:
+			if Identical(s.elem, t.elem) {
+				return true
+			}
+		case *Pointer:
+			if a, ok := t.base.Underlying().(*Array); ok && Identical(s.elem, a.elem) {
+				return true
+			}
+		}
+	}
+
 	// package unsafe:
 	// "any pointer or value of underlying type uintptr can be converted into a unsafe.Pointer"
 	if (isPointer(Vu) || isUintptr(Vu)) && isUnsafePointer(T) {
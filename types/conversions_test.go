@@ -0,0 +1,20 @@
+package types
+
+import "testing"
+
+func TestSliceToArrayConversion(t *testing.T) {
+	runCheckTests(t, []checkTest{
+		{src: "var s []int; var _ [2]int = [2]int(s)"},
+		{src: "var s []int; var _ *[2]int = (*[2]int)(s)"},
+		{src: "type A [2]int; type S []int; var s S; var _ = A(s); var _ = (*A)(s)"},
+		{src: "const N = 3; var s []int; var _ = [N]int(s); var _ = (*[N]int)(s)"},
+		// a shorter slice panics only at run time
+		{src: "var s = []int{1}; var _ = [2]int(s[:1])"},
+		{src: "var s []int; var _ = [0]int(s)"},
+		{src: "var s []int; var _ = [2]string(s)", errs: []string{"cannot convert"}},
+		{src: "var s []int; var _ = (*[2]int64)(s)", errs: []string{"cannot convert"}},
+		{src: "var _ = []int([2]int{})", errs: []string{"cannot convert"}},
+		{src: "var s []int; var _ = (*[]int)(s)", errs: []string{"cannot convert"}},
+		{src: "var a [3]int; var _ = [2]int(a)", errs: []string{"cannot convert"}},
+	})
+}
@@ -47,6 +47,10 @@ func (pkg *Package) Complete() bool { return pkg.complete }
 // MarkComplete marks a package as complete.
 func (pkg *Package) MarkComplete() { pkg.complete = true }
 
+// MarkIncomplete marks a package as incomplete, e.g. because
+// type-checking it failed part way through.
+func (pkg *Package) MarkIncomplete() { pkg.complete = false }
+
 // Imports returns the list of packages directly imported by
 // pkg; the list is in source order.
 //
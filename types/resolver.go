@@ -466,6 +466,9 @@ func (check *Checker) packageObjects(objList []Object) {
 	typePath := make([]*TypeName, 0, 8)
 
 	for _, obj := range objList {
+		if check.done() {
+			break
+		}
 		check.objDecl(obj, nil, typePath)
 	}
 
@@ -479,6 +482,9 @@ func (check *Checker) packageObjects(objList []Object) {
 // functionBodies typechecks all function bodies.
 func (check *Checker) functionBodies() {
 	for _, f := range check.funcs {
+		if check.done() {
+			break
+		}
 		check.funcBody(f.decl, f.name, f.sig, f.body)
 	}
 }
@@ -757,7 +757,36 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				if isString(typ) {
 					key = Typ[Int]
 					val = universeRune // use 'rune' name
+				} else if isInteger(typ) {
+					// range over int (Go 1.22): the iteration values
+					// are 0 to n-1, of the type of n (int if untyped)
+					if isUntyped(typ) {
+						if s.Tok == token.DEFINE || s.Key == nil {
+							check.convertUntyped(&x, Typ[Int])
+						} else {
+							// take the type of the assigned variable
+							var k operand
+							check.expr(&k, s.Key)
+							if k.mode != invalid {
+								check.convertUntyped(&x, k.typ)
+							}
+						}
+						if x.mode == invalid {
+							break
+						}
+					}
+					key = x.typ
+					val = Typ[Invalid]
+					if s.Value != nil {
+						check.errorf(s.Value.Pos(), "range over %s permits only one iteration variable", &x)
+						// ok to continue
+					}
 				}
+			case *Signature:
+				// range over func (Go 1.23): the function must have the
+				// form func(yield func(K, V) bool) with 0 to 2 parameters
+				// K, V of yield
+				key, val = check.rangeFuncKeyVal(s, &x, typ)
 			case *Array:
 				key = Typ[Int]
 				val = typ.elem
@@ -786,7 +815,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 		}
 
-		if key == nil {
+		if key == nil && x.mode != invalid {
 			check.errorf(x.pos(), "cannot range over %s", &x)
 			// ok to continue
 		}
@@ -870,3 +899,41 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		check.error(s.Pos(), "invalid statement")
 	}
 }
+
+// rangeFuncKeyVal returns the key and value types for ranging over the
+// iterator function x of type sig. Unused iteration values have type
+// Invalid; both are Invalid if x is not a valid iterator.
+func (check *Checker) rangeFuncKeyVal(s *ast.RangeStmt, x *operand, sig *Signature) (key, val Type) {
+	if sig.params.Len() != 1 || sig.results.Len() != 0 {
+		check.errorf(x.pos(), "cannot range over %s: func must be func(yield func(...) bool): wrong argument count", x)
+		return Typ[Invalid], Typ[Invalid]
+	}
+	yield, _ := sig.params.At(0).typ.Underlying().(*Signature)
+	if yield == nil {
+		check.errorf(x.pos(), "cannot range over %s: func must be func(yield func(...) bool): argument is not func", x)
+		return Typ[Invalid], Typ[Invalid]
+	}
+	if yield.results.Len() != 1 || !isBoolean(yield.results.At(0).typ) {
+		check.errorf(x.pos(), "cannot range over %s: yield func must return bool", x)
+		return Typ[Invalid], Typ[Invalid]
+	}
+	if yield.params.Len() > 2 {
+		check.errorf(x.pos(), "cannot range over %s: yield func has too many parameters", x)
+		return Typ[Invalid], Typ[Invalid]
+	}
+
+	key, val = Typ[Invalid], Typ[Invalid]
+	if n := yield.params.Len(); n > 0 {
+		key = yield.params.At(0).typ
+		if n > 1 {
+			val = yield.params.At(1).typ
+		}
+	}
+	switch n := yield.params.Len(); {
+	case n == 0 && s.Key != nil:
+		check.errorf(s.Key.Pos(), "range over %s permits no iteration variables", x)
+	case n == 1 && s.Value != nil:
+		check.errorf(s.Value.Pos(), "range over %s permits only one iteration variable", x)
+	}
+	return
+}
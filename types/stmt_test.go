@@ -0,0 +1,30 @@
+package types
+
+import "testing"
+
+func TestRangeOverInt(t *testing.T) {
+	runCheckTests(t, []checkTest{
+		{src: "func f() { for i := range 10 { var _ int = i } }"},
+		{src: "func f() { for range 10 {} }"},
+		{src: "func f(n uint8) { for i := range n { var _ uint8 = i } }"},
+		{src: "func f() { var i uint8; for i = range 10 { _ = i } }"},
+		{src: "func f() { var i uint8; for i = range 1000 { _ = i } }", errs: []string{"overflows"}},
+		{src: "func f() { for i, j := range 10 { _, _ = i, j } }", errs: []string{"permits only one iteration variable"}},
+		{src: "func f() { for range 1.5 {} }", errs: []string{"cannot range over"}},
+	})
+}
+
+func TestRangeOverFunc(t *testing.T) {
+	runCheckTests(t, []checkTest{
+		{src: "func f(seq func(func() bool)) { for range seq {} }"},
+		{src: "func f(seq func(func(string) bool)) { for s := range seq { var _ string = s } }"},
+		{src: "func f(seq func(func(int, string) bool)) { for i, s := range seq { var _ int = i; var _ string = s } }"},
+		{src: "func f(seq func(func(int, string) bool)) { for i := range seq { var _ int = i } }"},
+		{src: "func f(seq func(func() bool)) { for i := range seq { _ = i } }", errs: []string{"permits no iteration variables"}},
+		{src: "func f(seq func(func(int) bool)) { for i, j := range seq { _, _ = i, j } }", errs: []string{"permits only one iteration variable"}},
+		{src: "func f(seq func(func(int, int, int) bool)) { for range seq {} }", errs: []string{"yield func has too many parameters"}},
+		{src: "func f(seq func(func(int))) { for range seq {} }", errs: []string{"yield func must return bool"}},
+		{src: "func f(seq func(int)) { for range seq {} }", errs: []string{"argument is not func"}},
+		{src: "func f(seq func()) { for range seq {} }", errs: []string{"wrong argument count"}},
+	})
+}
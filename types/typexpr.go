@@ -529,13 +529,24 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 	//          those methods can be added to the list of all methods of this
 	//          interface.
 
+	//          As of Go 1.14, the embedded type may be any interface type,
+	//          including interface literals and aliases, and methods of
+	//          embedded interfaces may overlap with each other and with the
+	//          explicit methods as long as their signatures are identical.
+	//          The signatures are compared once they are all known.
+
+	type overlap struct {
+		pos      token.Pos
+		m, other *Func
+	}
+	var overlaps []overlap
+
 	for _, e := range embedded {
 		pos := e.Pos()
 		typ := check.typExpr(e, nil, path)
 		// Determine underlying embedded (possibly incomplete) type
 		// by following its forward chain.
-		named, _ := typ.(*Named)
-		under := underlying(named)
+		under := underlying(typ)
 		embed, _ := under.(*Interface)
 		if embed == nil {
 			if typ != Typ[Invalid] {
@@ -543,15 +554,19 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 			}
 			continue
 		}
-		iface.embeddeds = append(iface.embeddeds, named)
+		iface.embeddeds = append(iface.embeddeds, typ)
 		// collect embedded methods
 		if embed.allMethods == nil {
-			check.errorf(pos, "internal error: incomplete embedded interface %s (issue #18395)", named)
+			check.errorf(pos, "internal error: incomplete embedded interface %s (issue #18395)", typ)
 		}
 		for _, m := range embed.allMethods {
-			if check.declareInSet(&mset, pos, m) {
-				iface.allMethods = append(iface.allMethods, m)
+			if other := mset.insert(m); other != nil {
+				if other != m {
+					overlaps = append(overlaps, overlap{pos, m, other.(*Func)})
+				}
+				continue
 			}
+			iface.allMethods = append(iface.allMethods, m)
 		}
 	}
 
@@ -577,6 +592,13 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 		*old = *sig // update signature (don't replace it!)
 	}
 
+	for _, o := range overlaps {
+		if !Identical(o.m.typ, o.other.typ) {
+			check.errorf(o.pos, "duplicate method %s", o.m.name)
+			check.reportAltDecl(o.other)
+		}
+	}
+
 	// TODO(gri) The list of explicit methods is only sorted for now to
 	// produce the same Interface as NewInterface. We may be able to
 	// claim source order in the future. Revisit.
@@ -585,7 +607,7 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 	// TODO(gri) The list of embedded types is only sorted for now to
 	// produce the same Interface as NewInterface. We may be able to
 	// claim source order in the future. Revisit.
-	sort.Sort(byUniqueTypeName(iface.embeddeds))
+	sort.Stable(byUniqueTypeName(iface.embeddeds))
 
 	if iface.allMethods == nil {
 		iface.allMethods = make([]*Func, 0) // mark interface as complete
@@ -595,12 +617,20 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 }
 
 // byUniqueTypeName named type lists can be sorted by their unique type names.
-type byUniqueTypeName []*Named
+// Unnamed types sort before named ones.
+type byUniqueTypeName []Type
 
 func (a byUniqueTypeName) Len() int           { return len(a) }
-func (a byUniqueTypeName) Less(i, j int) bool { return a[i].obj.Id() < a[j].obj.Id() }
+func (a byUniqueTypeName) Less(i, j int) bool { return sortName(a[i]) < sortName(a[j]) }
 func (a byUniqueTypeName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
+func sortName(t Type) string {
+	if named, _ := t.(*Named); named != nil {
+		return named.obj.Id()
+	}
+	return ""
+}
+
 // byUniqueMethodName method lists can be sorted by their unique method names.
 type byUniqueMethodName []*Func
 